@@ -91,7 +91,8 @@ var adminRunForceCancelCmd = &cobra.Command{
 			var tmpRun Run
 
 			// get workspaceID from run
-			run, _ := getRun(client, id)
+			run, err := getRun(client, id)
+			check(err)
 			workspaceID := run.Workspace.ID
 
 			// get workspaceName from run
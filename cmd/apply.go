@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Query TFE Applies",
+	Long:  `Query TFE Applies.`,
+}
+
+var applyLogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the logs of an apply with given applyID",
+	Long: `Download the logs of an apply with given applyID.
+When the logs are in Terraform's JSON log format, the diagnostics are returned.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+
+		log.Debugf("Downloading logs of apply: %s", id)
+		logs, err := client.Applies.Logs(context.Background(), id)
+		check(err)
+
+		outputLogs(cmd, logs)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.AddCommand(applyLogsCmd)
+	addLogsFlags(applyLogsCmd, "ApplyID")
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type CostEstimate struct {
+	ID                    string  `json:"cost_estimate_id"`
+	Status                string  `json:"status"`
+	PriorMonthlyCost      float64 `json:"prior_monthly_cost"`
+	ProposedMonthlyCost   float64 `json:"proposed_monthly_cost"`
+	DeltaMonthlyCost      float64 `json:"delta_monthly_cost"`
+	ResourcesCount        int     `json:"resources_count"`
+	MatchedResourcesCount int     `json:"matched_resources_count"`
+	OverThreshold         bool    `json:"over_threshold"`
+}
+
+type RunCost struct {
+	RunID         string `json:"run_id"`
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceName string `json:"workspace_name"`
+	CostEstimate
+}
+
+type WorkspaceCost struct {
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceName string `json:"workspace_name"`
+	ProjectID     string `json:"project_id"`
+	RunID         string `json:"run_id"`
+	CostEstimate
+}
+
+type ProjectCost struct {
+	ProjectID           string  `json:"project_id"`
+	ProjectName         string  `json:"project_name"`
+	WorkspaceCount      int     `json:"workspace_count"`
+	PriorMonthlyCost    float64 `json:"prior_monthly_cost"`
+	ProposedMonthlyCost float64 `json:"proposed_monthly_cost"`
+	DeltaMonthlyCost    float64 `json:"delta_monthly_cost"`
+	OverThreshold       bool    `json:"over_threshold"`
+}
+
+var runCostCmd = &cobra.Command{
+	Use:   "cost",
+	Short: "Show the cost estimate of runs with given runIDs",
+	Long:  `Show the prior and proposed monthly cost, and their delta, of runs with given runIDs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		ids, _ := cmd.Flags().GetString("ids")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+
+		idList := splitIDs(ids)
+		if len(idList) == 0 {
+			log.Fatal("please provide the ids of the runs to perform this operation!")
+		}
+
+		var runCostList []RunCost
+
+		for _, id := range idList {
+			run, err := getRun(client, id)
+			check(err)
+
+			workspaceName, _ := getWorkspaceNameByID(client, organization, run.Workspace.ID)
+
+			tmpRunCost := RunCost{
+				RunID:         run.ID,
+				WorkspaceID:   run.Workspace.ID,
+				WorkspaceName: workspaceName,
+			}
+
+			if run.CostEstimate == nil {
+				log.Debugf("Run %s has no cost estimate", id)
+				tmpRunCost.Status = "unavailable"
+			} else {
+				tmpRunCost.CostEstimate, err = readCostEstimate(client, run.CostEstimate.ID, threshold)
+				check(err)
+			}
+
+			runCostList = append(runCostList, tmpRunCost)
+		}
+
+		runCostListJson, _ := json.MarshalIndent(runCostList, "", "  ")
+		outputData(cmd, runCostListJson)
+	},
+}
+
+var workspaceCostCmd = &cobra.Command{
+	Use:   "cost",
+	Short: "Report the latest cost estimate of TFE workspaces",
+	Long:  `Report the latest cost estimate of TFE workspaces, optionally aggregated per project.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		filter, _ := cmd.Flags().GetString("filter")
+		byProject, _ := cmd.Flags().GetBool("by-project")
+		sortBy, _ := cmd.Flags().GetString("sort")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+
+		if sortBy != "delta" && sortBy != "proposed" && sortBy != "name" {
+			log.Fatalf("unsupported sort %s, use one of delta, proposed or name", sortBy)
+		}
+
+		workspaces, err := listWorkspaces(client, organization, filter)
+		check(err)
+
+		var workspaceCostList []WorkspaceCost
+		for _, workspace := range workspaces {
+			log.Debugf("Processing workspace: %s - %s", workspace.Name, workspace.ID)
+
+			tmpWorkspaceCost, err := getLatestWorkspaceCost(client, workspace, threshold)
+			check(err)
+
+			workspaceCostList = append(workspaceCostList, tmpWorkspaceCost)
+		}
+
+		var costJson []byte
+
+		if byProject {
+			projectCostList, err := aggregateProjectCosts(client, workspaceCostList, threshold)
+			check(err)
+
+			sort.SliceStable(projectCostList, func(i, j int) bool {
+				switch sortBy {
+				case "proposed":
+					return projectCostList[i].ProposedMonthlyCost > projectCostList[j].ProposedMonthlyCost
+				case "name":
+					return projectCostList[i].ProjectName < projectCostList[j].ProjectName
+				}
+				return math.Abs(projectCostList[i].DeltaMonthlyCost) > math.Abs(projectCostList[j].DeltaMonthlyCost)
+			})
+
+			costJson, _ = json.MarshalIndent(projectCostList, "", "  ")
+		} else {
+			sort.SliceStable(workspaceCostList, func(i, j int) bool {
+				switch sortBy {
+				case "proposed":
+					return workspaceCostList[i].ProposedMonthlyCost > workspaceCostList[j].ProposedMonthlyCost
+				case "name":
+					return workspaceCostList[i].WorkspaceName < workspaceCostList[j].WorkspaceName
+				}
+				return math.Abs(workspaceCostList[i].DeltaMonthlyCost) > math.Abs(workspaceCostList[j].DeltaMonthlyCost)
+			})
+
+			costJson, _ = json.MarshalIndent(workspaceCostList, "", "  ")
+		}
+
+		outputData(cmd, costJson)
+	},
+}
+
+func init() {
+	// Cost sub-command of run
+	runCmd.AddCommand(runCostCmd)
+	runCostCmd.Flags().String("ids", "", "Query comma-separated string of runIDs")
+	runCostCmd.Flags().Float64("threshold", 0, "Flag runs whose absolute monthly cost delta exceeds this amount, 0 disables the check")
+
+	// Cost sub-command of workspace
+	workspaceCmd.AddCommand(workspaceCostCmd)
+	workspaceCostCmd.Flags().String("filter", "", "Filter workspaces by name or by tag\nTo filter by tag, prefix filter with \"tags|\"")
+	workspaceCostCmd.Flags().Bool("by-project", false, "Aggregate the cost estimates per project")
+	workspaceCostCmd.Flags().String("sort", "delta", "Sort the report by delta, proposed or name")
+	workspaceCostCmd.Flags().Float64("threshold", 0, "Flag workspaces whose absolute monthly cost delta exceeds this amount, 0 disables the check")
+}
+
+func readCostEstimate(client *tfe.Client, costEstimateID string, threshold float64) (CostEstimate, error) {
+	result := CostEstimate{}
+
+	log.Debugf("Querying cost estimate with id: %s", costEstimateID)
+	ce, err := client.CostEstimates.Read(context.Background(), costEstimateID)
+	if err != nil {
+		return result, err
+	}
+
+	result.ID = ce.ID
+	result.Status = string(ce.Status)
+	result.PriorMonthlyCost = parseCost(ce.PriorMonthlyCost)
+	result.ProposedMonthlyCost = parseCost(ce.ProposedMonthlyCost)
+	result.DeltaMonthlyCost = parseCost(ce.DeltaMonthlyCost)
+	result.ResourcesCount = ce.ResourcesCount
+	result.MatchedResourcesCount = ce.MatchedResourcesCount
+	result.OverThreshold = overCostThreshold(result.DeltaMonthlyCost, threshold)
+
+	return result, nil
+}
+
+// getLatestWorkspaceCost returns the cost estimate of the most recent run of
+// the workspace which completed a cost estimation.
+func getLatestWorkspaceCost(client *tfe.Client, workspace *tfe.Workspace, threshold float64) (WorkspaceCost, error) {
+	result := WorkspaceCost{
+		WorkspaceID:   workspace.ID,
+		WorkspaceName: workspace.Name,
+	}
+	result.Status = "unavailable"
+
+	if workspace.Project != nil {
+		result.ProjectID = workspace.Project.ID
+	}
+
+	runs, err := listRuns(client, workspace.ID, "", "", false)
+	if err != nil {
+		return result, err
+	}
+
+	for _, run := range runs {
+		if run.CostEstimate == nil {
+			continue
+		}
+
+		ce, err := readCostEstimate(client, run.CostEstimate.ID, threshold)
+		if err != nil {
+			return result, err
+		}
+
+		if ce.Status != string(tfe.CostEstimateFinished) {
+			continue
+		}
+
+		result.RunID = run.ID
+		result.CostEstimate = ce
+		break
+	}
+
+	return result, nil
+}
+
+func aggregateProjectCosts(client *tfe.Client, workspaceCosts []WorkspaceCost, threshold float64) ([]ProjectCost, error) {
+	results := []ProjectCost{}
+	index := map[string]int{}
+
+	for _, wc := range workspaceCosts {
+		i, ok := index[wc.ProjectID]
+		if !ok {
+			projectName := ""
+			if wc.ProjectID != "" {
+				p, err := client.Projects.Read(context.Background(), wc.ProjectID)
+				if err != nil {
+					return nil, err
+				}
+				projectName = p.Name
+			}
+
+			results = append(results, ProjectCost{
+				ProjectID:   wc.ProjectID,
+				ProjectName: projectName,
+			})
+			i = len(results) - 1
+			index[wc.ProjectID] = i
+		}
+
+		results[i].WorkspaceCount++
+		results[i].PriorMonthlyCost += wc.PriorMonthlyCost
+		results[i].ProposedMonthlyCost += wc.ProposedMonthlyCost
+		results[i].DeltaMonthlyCost += wc.DeltaMonthlyCost
+	}
+
+	for i := range results {
+		results[i].OverThreshold = overCostThreshold(results[i].DeltaMonthlyCost, threshold)
+	}
+
+	return results, nil
+}
+
+func parseCost(cost string) float64 {
+	if cost == "" {
+		return 0
+	}
+
+	value, err := strconv.ParseFloat(cost, 64)
+	if err != nil {
+		log.Warnf("Unable to parse cost %s: %v", cost, err)
+		return 0
+	}
+
+	return value
+}
+
+func overCostThreshold(delta float64, threshold float64) bool {
+	return threshold > 0 && math.Abs(delta) > threshold
+}
@@ -47,3 +47,17 @@ func runTestCasesWithOutput(t *testing.T, c *cobra.Command, tt []struct {
 		}
 	}
 }
+
+// splitIDs splits a comma separated list of IDs, ignoring empty entries.
+func splitIDs(ids string) []string {
+	var results []string
+
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" {
+			results = append(results, id)
+		}
+	}
+
+	return results
+}
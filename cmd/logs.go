@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type LogDiagnostic struct {
+	Severity    string `json:"severity"`
+	Summary     string `json:"summary"`
+	Detail      string `json:"detail"`
+	Address     string `json:"address"`
+	Filename    string `json:"filename"`
+	StartLine   int    `json:"start_line"`
+	StartColumn int    `json:"start_column"`
+	EndLine     int    `json:"end_line"`
+	EndColumn   int    `json:"end_column"`
+}
+
+// Terraform JSON log line, see https://developer.hashicorp.com/terraform/internals/machine-readable-ui
+type jsonLogLine struct {
+	Level      string             `json:"@level"`
+	Message    string             `json:"@message"`
+	Type       string             `json:"type"`
+	Diagnostic *jsonLogDiagnostic `json:"diagnostic"`
+}
+
+type jsonLogDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+	Address  string `json:"address"`
+	Range    *struct {
+		Filename string     `json:"filename"`
+		Start    jsonLogPos `json:"start"`
+		End      jsonLogPos `json:"end"`
+	} `json:"range"`
+}
+
+type jsonLogPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func addLogsFlags(cmd *cobra.Command, idName string) {
+	cmd.Flags().String("id", "", idName+" to download the logs of")
+	cmd.Flags().Bool("raw", false, "Print the raw logs instead of the parsed diagnostics")
+	cmd.Flags().String("out", "", "File to save the raw logs to")
+	cmd.Flags().String("severity", "", "Only return the diagnostics of this severity (error or warning)")
+}
+
+// outputLogs saves or prints the raw logs as requested by the flags of cmd,
+// otherwise outputs the diagnostics parsed from the logs.
+func outputLogs(cmd *cobra.Command, logs io.Reader) {
+	raw, _ := cmd.Flags().GetBool("raw")
+	out, _ := cmd.Flags().GetString("out")
+	severity, _ := cmd.Flags().GetString("severity")
+
+	data, err := io.ReadAll(logs)
+	check(err)
+
+	if out != "" {
+		err = os.WriteFile(out, data, 0600)
+		check(err)
+		log.Infof("Logs saved to %s", out)
+	}
+
+	if raw {
+		cmd.Print(string(data))
+		return
+	}
+
+	diagnostics, jsonLogs := parseLogDiagnostics(data)
+	if !jsonLogs {
+		log.Warn("logs are not in Terraform's JSON log format, use --raw to print them")
+	}
+	diagnostics = filterLogDiagnostics(diagnostics, severity)
+
+	diagnosticsJson, _ := json.MarshalIndent(diagnostics, "", "  ")
+	outputData(cmd, diagnosticsJson)
+}
+
+// parseLogDiagnostics extracts the diagnostics of Terraform JSON logs, lines
+// which are not JSON are skipped. It also reports whether any JSON log line
+// was found.
+func parseLogDiagnostics(data []byte) ([]LogDiagnostic, bool) {
+	results := []LogDiagnostic{}
+	jsonLogs := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	for scanner.Scan() {
+		// Logs are wrapped in STX/ETX control characters by TFE
+		line := strings.Trim(scanner.Text(), "\x02\x03\r ")
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var entry jsonLogLine
+		if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Level == "" {
+			continue
+		}
+		jsonLogs = true
+
+		if entry.Type != "diagnostic" || entry.Diagnostic == nil {
+			continue
+		}
+
+		d := entry.Diagnostic
+		diagnostic := LogDiagnostic{
+			Severity: d.Severity,
+			Summary:  d.Summary,
+			Detail:   d.Detail,
+			Address:  d.Address,
+		}
+
+		if d.Range != nil {
+			diagnostic.Filename = d.Range.Filename
+			diagnostic.StartLine = d.Range.Start.Line
+			diagnostic.StartColumn = d.Range.Start.Column
+			diagnostic.EndLine = d.Range.End.Line
+			diagnostic.EndColumn = d.Range.End.Column
+		}
+
+		results = append(results, diagnostic)
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Warnf("unable to read all logs: %v", err)
+	}
+
+	return results, jsonLogs
+}
+
+// filterLogDiagnostics returns the diagnostics of the severity, or all of them
+// when severity is empty
+func filterLogDiagnostics(diagnostics []LogDiagnostic, severity string) []LogDiagnostic {
+	if severity == "" {
+		return diagnostics
+	}
+
+	results := []LogDiagnostic{}
+	for _, d := range diagnostics {
+		if strings.EqualFold(d.Severity, severity) {
+			results = append(results, d)
+		}
+	}
+
+	return results
+}
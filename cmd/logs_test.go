@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const logsTestDiagnostics = "\x02Terraform v1.9.0\n" +
+	`{"@level":"info","@message":"Terraform 1.9.0","type":"version"}` + "\n" +
+	"Initializing plugins...\n" +
+	`{"@level":"warn","@message":"Warning: Deprecated attribute","type":"diagnostic","diagnostic":{"severity":"warning","summary":"Deprecated attribute","detail":"Use tags_all instead"}}` + "\r\n" +
+	`{"@level":"error","@message":"Error: Invalid reference","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid reference","detail":"A reference must start with a name","address":"aws_instance.web","range":{"filename":"main.tf","start":{"line":12,"column":3},"end":{"line":12,"column":18}}}}` + "\n" +
+	`{"not": "a log line"}` + "\n" +
+	`{"@level":"info","type":"diagnostic"}` + "\x03\n"
+
+func TestParseLogDiagnostics(t *testing.T) {
+	warning := LogDiagnostic{Severity: "warning", Summary: "Deprecated attribute", Detail: "Use tags_all instead"}
+	reference := LogDiagnostic{
+		Severity:    "error",
+		Summary:     "Invalid reference",
+		Detail:      "A reference must start with a name",
+		Address:     "aws_instance.web",
+		Filename:    "main.tf",
+		StartLine:   12,
+		StartColumn: 3,
+		EndLine:     12,
+		EndColumn:   18,
+	}
+
+	tt := []struct {
+		name     string
+		logs     string
+		want     []LogDiagnostic
+		jsonLogs bool
+	}{
+		{
+			name:     "mixed json and plain lines",
+			logs:     logsTestDiagnostics,
+			want:     []LogDiagnostic{warning, reference},
+			jsonLogs: true,
+		},
+		{
+			name:     "json logs without diagnostics",
+			logs:     `{"@level":"info","@message":"Plan: 1 to add","type":"change_summary"}`,
+			want:     []LogDiagnostic{},
+			jsonLogs: true,
+		},
+		{
+			name: "plain logs",
+			logs: "Terraform v1.9.0\nError: Invalid reference\n  on main.tf line 12\n",
+			want: []LogDiagnostic{},
+		},
+		{
+			name: "invalid json",
+			logs: `{"@level":"error",` + "\n",
+			want: []LogDiagnostic{},
+		},
+		{
+			name: "empty logs",
+			want: []LogDiagnostic{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, jsonLogs := parseLogDiagnostics([]byte(tc.logs))
+
+			require.Equal(t, tc.jsonLogs, jsonLogs)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestFilterLogDiagnostics(t *testing.T) {
+	diagnostics, _ := parseLogDiagnostics([]byte(logsTestDiagnostics))
+
+	tt := []struct {
+		severity string
+		want     []string
+	}{
+		{"", []string{"Deprecated attribute", "Invalid reference"}},
+		{"error", []string{"Invalid reference"}},
+		{"WARNING", []string{"Deprecated attribute"}},
+		{"info", []string{}},
+	}
+
+	for _, tc := range tt {
+		summaries := []string{}
+		for _, d := range filterLogDiagnostics(diagnostics, tc.severity) {
+			summaries = append(summaries, d.Summary)
+		}
+
+		require.Equal(t, tc.want, summaries, tc.severity)
+	}
+}
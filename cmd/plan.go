@@ -66,12 +66,34 @@ var planShowCmd = &cobra.Command{
 	},
 }
 
+var planLogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the logs of a plan with given planID",
+	Long: `Download the logs of a plan with given planID.
+When the logs are in Terraform's JSON log format, the diagnostics are returned.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+
+		log.Debugf("Downloading logs of plan: %s", id)
+		logs, err := client.Plans.Logs(context.Background(), id)
+		check(err)
+
+		outputLogs(cmd, logs)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(planCmd)
 	planCmd.AddCommand(planShowCmd)
+	planCmd.AddCommand(planLogsCmd)
 
 	planShowCmd.Flags().String("ids", "", "Query comma-separated string of planIDs")
 	planShowCmd.Flags().Bool("detailed-changes", false, "Returns a map describing the changed resource attributes")
+
+	addLogsFlags(planLogsCmd, "PlanID")
 }
 
 func showPlan(client *tfe.Client, planID string, detailedChanges bool) (Plan, error) {
@@ -109,14 +131,13 @@ func showPlan(client *tfe.Client, planID string, detailedChanges bool) (Plan, er
       attribute_changes: (
         if .change.before != null and .change.after != null then
           # Object is being updated
-          (.change.before | to_entries) as $before_entries |
-          (.change.after | to_entries) as $after_entries |
-          reduce $before_entries[] as $item ({};
-            # Compare attribute changes between the change.before and change.after map 
-            # for the given resource
-            # TODO: Extend to include new attributes created in change.after map
-            if $item.value != ($after_entries[] | select(.key == $item.key) | .value) then
-              . + {($item.key): "(\($item.value)) -> (\($after_entries[] | select(.key == $item.key) | .value))"}
+          .change.before as $before |
+          .change.after as $after |
+          reduce ((($before | keys) + ($after | keys)) | unique)[] as $key ({};
+            # Compare attribute changes between the change.before and change.after map
+            # for the given resource, including attributes only present in change.after
+            if $before[$key] != $after[$key] then
+              . + {($key): "(\($before[$key])) -> (\($after[$key]))"}
             else
               .
             end
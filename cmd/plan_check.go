@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	"gopkg.in/yaml.v3"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Weight of each violation severity in the risk score of a plan
+var planRuleSeverityWeights = map[string]int{
+	"blocking": 10,
+	"warning":  5,
+	"info":     1,
+}
+
+type PlanRules struct {
+	Rules []PlanRule `yaml:"rules"`
+}
+
+// PlanRule matches resource changes of a plan, every non-empty selector has to
+// match for a resource change to match the rule.
+type PlanRule struct {
+	Name          string            `yaml:"name"`
+	Description   string            `yaml:"description"`
+	Severity      string            `yaml:"severity"`
+	ResourceTypes []string          `yaml:"resource_types"`
+	Addresses     []string          `yaml:"addresses"`
+	Actions       []string          `yaml:"actions"`
+	Tags          map[string]string `yaml:"tags"`
+	Attributes    []string          `yaml:"attributes"`
+	MaxCount      int               `yaml:"max_count"`
+}
+
+type PlanViolation struct {
+	Rule        string `json:"rule"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	Address     string `json:"address"`
+	Action      string `json:"action"`
+	Message     string `json:"message"`
+}
+
+type PlanCheck struct {
+	PlanID             string          `json:"plan_id"`
+	RiskScore          int             `json:"risk_score"`
+	BlockingViolations int             `json:"blocking_violations"`
+	Violations         []PlanViolation `json:"violations"`
+}
+
+var planCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the changes of a plan against guardrail rules",
+	Long: `Check the changes of a plan against guardrail rules defined in a YAML file.
+Exits with a non-zero status when a blocking rule is violated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+		rulesFile, _ := cmd.Flags().GetString("rules")
+
+		rules, err := readPlanRules(rulesFile)
+		check(err)
+
+		plan, err := readPlanJSON(client, id)
+		check(err)
+
+		planCheck := checkPlan(id, plan, rules)
+
+		planCheckJson, _ := json.MarshalIndent([]PlanCheck{planCheck}, "", "  ")
+		outputData(cmd, planCheckJson)
+
+		if planCheck.BlockingViolations > 0 {
+			log.Fatalf("plan %s has %d blocking violations", id, planCheck.BlockingViolations)
+		}
+	},
+}
+
+func init() {
+	planCmd.AddCommand(planCheckCmd)
+
+	planCheckCmd.Flags().String("id", "", "PlanID to check")
+	planCheckCmd.Flags().String("rules", "", "YAML file containing the rules to check the plan against")
+}
+
+func readPlanRules(file string) (PlanRules, error) {
+	var result PlanRules
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return result, err
+	}
+
+	err = yaml.Unmarshal(content, &result)
+	if err != nil {
+		return result, fmt.Errorf("unable to parse rules file %s: %v", file, err)
+	}
+
+	for i, rule := range result.Rules {
+		if rule.Severity == "" {
+			result.Rules[i].Severity = "blocking"
+		}
+		if _, ok := planRuleSeverityWeights[result.Rules[i].Severity]; !ok {
+			return result, fmt.Errorf("rule %s has unsupported severity %s, use one of blocking, warning or info", rule.Name, rule.Severity)
+		}
+	}
+
+	return result, nil
+}
+
+func checkPlan(planID string, plan PlanJSON, rules PlanRules) PlanCheck {
+	result := PlanCheck{
+		PlanID:     planID,
+		Violations: []PlanViolation{},
+	}
+
+	for _, rule := range rules.Rules {
+		var matches []PlanResourceChange
+
+		for _, rc := range plan.ResourceChanges {
+			if rule.matches(rc) {
+				matches = append(matches, rc)
+			}
+		}
+
+		if len(matches) <= rule.MaxCount {
+			continue
+		}
+
+		log.Debugf("Rule %s matches %d resources", rule.Name, len(matches))
+
+		// A rule with a max_count is violated once by all its matches together
+		if rule.MaxCount > 0 {
+			var addresses []string
+			for _, rc := range matches {
+				addresses = append(addresses, rc.Address)
+			}
+
+			result.addViolation(PlanViolation{
+				Rule:        rule.Name,
+				Severity:    rule.Severity,
+				Description: rule.Description,
+				Address:     strings.Join(addresses, ","),
+				Message:     fmt.Sprintf("%d resources match, at most %d allowed", len(matches), rule.MaxCount),
+			})
+			continue
+		}
+
+		for _, rc := range matches {
+			result.addViolation(PlanViolation{
+				Rule:        rule.Name,
+				Severity:    rule.Severity,
+				Description: rule.Description,
+				Address:     rc.Address,
+				Action:      rc.Change.Action(),
+				Message:     fmt.Sprintf("%s of %s is not allowed", rc.Change.Action(), rc.Address),
+			})
+		}
+	}
+
+	return result
+}
+
+func (c *PlanCheck) addViolation(v PlanViolation) {
+	c.Violations = append(c.Violations, v)
+
+	c.RiskScore += planRuleSeverityWeights[v.Severity]
+	if v.Severity == "blocking" {
+		c.BlockingViolations++
+	}
+}
+
+func (r PlanRule) matches(rc PlanResourceChange) bool {
+	action := rc.Change.Action()
+	if action == "no-op" {
+		return false
+	}
+
+	if len(r.Actions) > 0 && !containsString(r.Actions, action) {
+		return false
+	}
+
+	if len(r.ResourceTypes) > 0 && !matchesAnyGlob(r.ResourceTypes, rc.Type) {
+		return false
+	}
+
+	if len(r.Addresses) > 0 && !matchesAnyGlob(r.Addresses, rc.Address) {
+		return false
+	}
+
+	if len(r.Tags) > 0 && !resourceHasTags(rc, r.Tags) {
+		return false
+	}
+
+	if len(r.Attributes) > 0 {
+		changed := false
+		for _, ac := range rc.Change.AttributeChanges() {
+			if ac.Kind != attributeUnchanged && matchesAnyGlob(r.Attributes, ac.Path) {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resourceHasTags reports whether the resource carries one of the tags,
+// before or after the change. Tag values support glob patterns.
+func resourceHasTags(rc PlanResourceChange, tags map[string]string) bool {
+	for _, state := range []any{rc.Change.Before, rc.Change.After} {
+		for _, attribute := range []string{"tags", "tags_all", "labels"} {
+			resourceTags := asMap(asMap(state)[attribute])
+
+			for key, pattern := range tags {
+				value, ok := resourceTags[key].(string)
+				if !ok {
+					continue
+				}
+				if matched, _ := path.Match(pattern, value); matched {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func matchesAnyGlob(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const planCheckTestPlan = `{
+  "resource_changes": [
+    {
+      "address": "aws_s3_bucket.logs",
+      "type": "aws_s3_bucket",
+      "change": {"actions": ["delete"], "before": {"bucket": "logs", "tags": {"env": "prd"}}, "after": null}
+    },
+    {
+      "address": "module.app.aws_instance.web[0]",
+      "module_address": "module.app",
+      "type": "aws_instance",
+      "change": {"actions": ["delete", "create"], "before": {"ami": "ami-1", "tags": {"env": "dev"}}, "after": {"ami": "ami-2", "tags": {"env": "dev"}}}
+    },
+    {
+      "address": "module.app.aws_instance.web[1]",
+      "module_address": "module.app",
+      "type": "aws_instance",
+      "change": {"actions": ["update"], "before": {"ami": "ami-1", "tags": {"env": "dev"}}, "after": {"ami": "ami-1", "tags": {"env": "test"}}}
+    },
+    {
+      "address": "aws_iam_role.ci",
+      "type": "aws_iam_role",
+      "change": {"actions": ["no-op"], "before": {"name": "ci"}, "after": {"name": "ci"}}
+    }
+  ]
+}`
+
+func TestCheckPlan(t *testing.T) {
+	var plan PlanJSON
+	require.NoError(t, json.Unmarshal([]byte(planCheckTestPlan), &plan))
+
+	tt := []struct {
+		name      string
+		rule      PlanRule
+		addresses []string
+		riskScore int
+		blocking  int
+	}{
+		{
+			name:      "action",
+			rule:      PlanRule{Name: "no-delete", Severity: "blocking", Actions: []string{"delete"}},
+			addresses: []string{"aws_s3_bucket.logs"},
+			riskScore: 10,
+			blocking:  1,
+		},
+		{
+			name:      "replace is its own action",
+			rule:      PlanRule{Name: "no-replace", Severity: "warning", Actions: []string{"replace"}},
+			addresses: []string{"module.app.aws_instance.web[0]"},
+			riskScore: 5,
+		},
+		{
+			name:      "resource type glob",
+			rule:      PlanRule{Name: "instances", Severity: "info", ResourceTypes: []string{"aws_inst*"}},
+			addresses: []string{"module.app.aws_instance.web[0]", "module.app.aws_instance.web[1]"},
+			riskScore: 2,
+		},
+		{
+			name:      "address glob",
+			rule:      PlanRule{Name: "app", Severity: "info", Addresses: []string{"module.app.*"}},
+			addresses: []string{"module.app.aws_instance.web[0]", "module.app.aws_instance.web[1]"},
+			riskScore: 2,
+		},
+		{
+			name:      "tags before or after the change",
+			rule:      PlanRule{Name: "prd", Severity: "blocking", Tags: map[string]string{"env": "pr*"}},
+			addresses: []string{"aws_s3_bucket.logs"},
+			riskScore: 10,
+			blocking:  1,
+		},
+		{
+			name:      "changed attribute",
+			rule:      PlanRule{Name: "ami", Severity: "warning", Attributes: []string{"ami"}},
+			addresses: []string{"module.app.aws_instance.web[0]"},
+			riskScore: 5,
+		},
+		{
+			name:      "nested changed attribute",
+			rule:      PlanRule{Name: "tags", Severity: "warning", Attributes: []string{"tags.*"}},
+			addresses: []string{"aws_s3_bucket.logs", "module.app.aws_instance.web[1]"},
+			riskScore: 10,
+		},
+		{
+			name:      "all selectors have to match",
+			rule:      PlanRule{Name: "none", Severity: "blocking", Actions: []string{"delete"}, ResourceTypes: []string{"aws_instance"}},
+			addresses: []string{},
+		},
+		{
+			name:      "no-op changes never match",
+			rule:      PlanRule{Name: "roles", Severity: "blocking", ResourceTypes: []string{"aws_iam_role"}},
+			addresses: []string{},
+		},
+		{
+			name:      "within max count",
+			rule:      PlanRule{Name: "max", Severity: "blocking", ResourceTypes: []string{"aws_instance"}, MaxCount: 2},
+			addresses: []string{},
+		},
+		{
+			name:      "over max count",
+			rule:      PlanRule{Name: "max", Severity: "blocking", Addresses: []string{"*"}, MaxCount: 2},
+			addresses: []string{"aws_s3_bucket.logs,module.app.aws_instance.web[0],module.app.aws_instance.web[1]"},
+			riskScore: 10,
+			blocking:  1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := checkPlan("plan-1", plan, PlanRules{Rules: []PlanRule{tc.rule}})
+
+			addresses := []string{}
+			for _, v := range result.Violations {
+				require.Equal(t, tc.rule.Name, v.Rule)
+				require.Equal(t, tc.rule.Severity, v.Severity)
+				addresses = append(addresses, v.Address)
+			}
+
+			require.Equal(t, "plan-1", result.PlanID)
+			require.Equal(t, tc.addresses, addresses)
+			require.Equal(t, tc.riskScore, result.RiskScore)
+			require.Equal(t, tc.blocking, result.BlockingViolations)
+		})
+	}
+}
+
+func TestCheckPlanMaxCountMessage(t *testing.T) {
+	var plan PlanJSON
+	require.NoError(t, json.Unmarshal([]byte(planCheckTestPlan), &plan))
+
+	result := checkPlan("plan-1", plan, PlanRules{Rules: []PlanRule{
+		{Name: "max", Severity: "warning", ResourceTypes: []string{"aws_instance"}, MaxCount: 1},
+		{Name: "no-delete", Severity: "warning", Actions: []string{"delete"}},
+	}})
+
+	require.Len(t, result.Violations, 2)
+	require.Equal(t, "2 resources match, at most 1 allowed", result.Violations[0].Message)
+	require.Equal(t, "module.app.aws_instance.web[0],module.app.aws_instance.web[1]", result.Violations[0].Address)
+	require.Equal(t, "", result.Violations[0].Action)
+	require.Equal(t, "delete of aws_s3_bucket.logs is not allowed", result.Violations[1].Message)
+	require.Equal(t, 10, result.RiskScore)
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type PlanAttributeDifference struct {
+	Path string `json:"path"`
+	A    string `json:"a"`
+	B    string `json:"b"`
+}
+
+type PlanComparison struct {
+	Address    string                    `json:"address"`
+	Status     string                    `json:"status"`
+	ActionA    string                    `json:"action_a"`
+	ActionB    string                    `json:"action_b"`
+	Attributes []PlanAttributeDifference `json:"attributes"`
+}
+
+var planCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare the changes of two plans",
+	Long: `Compare the changes of two plans, e.g. the plans of the same configuration in staging and production.
+Resource addresses are normalised by removing module instance keys before they are compared.
+The keys of module calls with several instances in one of the plans are kept, so that their instances
+are compared one by one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		a, _ := cmd.Flags().GetString("a")
+		b, _ := cmd.Flags().GetString("b")
+		ignore, _ := cmd.Flags().GetString("ignore")
+		showAll, _ := cmd.Flags().GetBool("all")
+
+		if a == "" || b == "" {
+			log.Fatal("please provide both a and b planIDs to perform this operation!")
+		}
+
+		var ignoreList []string
+		if ignore != "" {
+			ignoreList = strings.Split(ignore, ",")
+		}
+
+		planA, err := readPlanJSON(client, a)
+		check(err)
+
+		planB, err := readPlanJSON(client, b)
+		check(err)
+
+		comparison := comparePlans(planA, planB, ignoreList, showAll)
+
+		comparisonJson, _ := json.MarshalIndent(comparison, "", "  ")
+		outputData(cmd, comparisonJson)
+	},
+}
+
+func init() {
+	planCmd.AddCommand(planCompareCmd)
+
+	planCompareCmd.Flags().String("a", "", "PlanID of the first plan")
+	planCompareCmd.Flags().String("b", "", "PlanID of the second plan")
+	planCompareCmd.Flags().String("ignore", "", "Comma-separated list of attributes to ignore, supports glob patterns e.g. \"tags.*\"")
+	planCompareCmd.Flags().Bool("all", false, "Also return resources whose changes are identical in both plans")
+}
+
+// normaliseAddress strips the instance key of every module call in the
+// address, e.g. ["staging"] in module.app["staging"].aws_s3_bucket.this,
+// except for the calls in keep
+func normaliseAddress(address string, keep map[string]bool) string {
+	steps := splitAddress(address)
+
+	var call []string
+	for i := 0; i+1 < len(steps) && steps[i] == "module"; i += 2 {
+		name, _ := splitInstanceKey(steps[i+1])
+		call = append(call, "module", name)
+		if !keep[strings.Join(call, ".")] {
+			steps[i+1] = name
+		}
+	}
+
+	return strings.Join(steps, ".")
+}
+
+// multiInstanceModules returns the module calls, such as module.app or
+// module.app.module.db, with more than one instance in one of the plans.
+// Their instance keys are kept so that the instances aren't merged.
+func multiInstanceModules(plans ...PlanJSON) map[string]bool {
+	results := map[string]bool{}
+
+	for _, plan := range plans {
+		keys := map[string]map[string]bool{}
+
+		for _, rc := range plan.ResourceChanges {
+			steps := splitAddress(rc.Address)
+
+			var call []string
+			for i := 0; i+1 < len(steps) && steps[i] == "module"; i += 2 {
+				name, key := splitInstanceKey(steps[i+1])
+				call = append(call, "module", name)
+
+				id := strings.Join(call, ".")
+				if keys[id] == nil {
+					keys[id] = map[string]bool{}
+				}
+				keys[id][key] = true
+
+				if len(keys[id]) > 1 {
+					results[id] = true
+				}
+			}
+		}
+	}
+
+	return results
+}
+
+// splitInstanceKey splits a step such as app["staging"] into its name and instance key
+func splitInstanceKey(step string) (string, string) {
+	if k := strings.IndexByte(step, '['); k >= 0 {
+		return step[:k], step[k:]
+	}
+
+	return step, ""
+}
+
+// splitAddress splits a resource address on the dots outside of instance keys,
+// which can be quoted strings containing dots and brackets
+func splitAddress(address string) []string {
+	var results []string
+	start := 0
+	depth := 0
+	quoted := false
+
+	for i := 0; i < len(address); i++ {
+		c := address[i]
+
+		switch {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '.' && depth == 0:
+			results = append(results, address[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(results, address[start:])
+}
+
+func comparePlans(planA PlanJSON, planB PlanJSON, ignore []string, showAll bool) []PlanComparison {
+	results := []PlanComparison{}
+
+	keep := multiInstanceModules(planA, planB)
+	changesA := indexResourceChanges(planA, keep)
+	changesB := indexResourceChanges(planB, keep)
+
+	addresses := map[string]bool{}
+	for address := range changesA {
+		addresses[address] = true
+	}
+	for address := range changesB {
+		addresses[address] = true
+	}
+
+	var sortedAddresses []string
+	for address := range addresses {
+		sortedAddresses = append(sortedAddresses, address)
+	}
+	sort.Strings(sortedAddresses)
+
+	for _, address := range sortedAddresses {
+		rcA, inA := changesA[address]
+		rcB, inB := changesB[address]
+
+		result := PlanComparison{
+			Address:    address,
+			Attributes: []PlanAttributeDifference{},
+		}
+
+		if inA {
+			result.ActionA = rcA.Change.Action()
+		}
+		if inB {
+			result.ActionB = rcB.Change.Action()
+		}
+
+		switch {
+		case !inB:
+			result.Status = "only_in_a"
+		case !inA:
+			result.Status = "only_in_b"
+		default:
+			result.Attributes = compareAttributeChanges(rcA.Change, rcB.Change, ignore)
+
+			switch {
+			case result.ActionA != result.ActionB:
+				result.Status = "actions_differ"
+			case len(result.Attributes) > 0:
+				result.Status = "attributes_differ"
+			default:
+				result.Status = "identical"
+			}
+		}
+
+		if result.Status == "identical" && !showAll {
+			continue
+		}
+
+		// Resources without changes in one plan and absent from the other aren't relevant
+		if (result.Status == "only_in_a" && result.ActionA == "no-op") || (result.Status == "only_in_b" && result.ActionB == "no-op") {
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func indexResourceChanges(plan PlanJSON, keep map[string]bool) map[string]PlanResourceChange {
+	results := map[string]PlanResourceChange{}
+
+	for _, rc := range plan.ResourceChanges {
+		results[normaliseAddress(rc.Address, keep)] = rc
+	}
+
+	return results
+}
+
+func compareAttributeChanges(changeA PlanChange, changeB PlanChange, ignore []string) []PlanAttributeDifference {
+	results := []PlanAttributeDifference{}
+
+	attributesA := indexAttributeChanges(changeA, ignore)
+	attributesB := indexAttributeChanges(changeB, ignore)
+
+	paths := map[string]bool{}
+	for p := range attributesA {
+		paths[p] = true
+	}
+	for p := range attributesB {
+		paths[p] = true
+	}
+
+	var sortedPaths []string
+	for p := range paths {
+		sortedPaths = append(sortedPaths, p)
+	}
+	sort.Strings(sortedPaths)
+
+	for _, p := range sortedPaths {
+		a := describeAttributeChange(attributesA[p])
+		b := describeAttributeChange(attributesB[p])
+
+		if a != b {
+			results = append(results, PlanAttributeDifference{Path: p, A: a, B: b})
+		}
+	}
+
+	return results
+}
+
+// indexAttributeChanges returns the changed attributes of the change by path,
+// leaving out the ignored attributes.
+func indexAttributeChanges(change PlanChange, ignore []string) map[string]*AttributeChange {
+	results := map[string]*AttributeChange{}
+
+	for _, ac := range change.AttributeChanges() {
+		if ac.Kind == attributeUnchanged || isIgnoredAttribute(ac.Path, ignore) {
+			continue
+		}
+
+		ac := ac
+		results[ac.Path] = &ac
+	}
+
+	return results
+}
+
+func isIgnoredAttribute(attributePath string, ignore []string) bool {
+	for _, pattern := range ignore {
+		if pattern == attributePath || strings.HasPrefix(attributePath, pattern+".") || strings.HasPrefix(attributePath, pattern+"[") {
+			return true
+		}
+		if matched, _ := path.Match(pattern, attributePath); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func describeAttributeChange(ac *AttributeChange) string {
+	if ac == nil {
+		return "unchanged"
+	}
+
+	before := formatAttributeValue(ac.Before, ac.Sensitive, false)
+	after := formatAttributeValue(ac.After, ac.Sensitive, ac.Unknown)
+
+	switch ac.Kind {
+	case attributeAdded:
+		return fmt.Sprintf("added: %s", after)
+	case attributeRemoved:
+		return fmt.Sprintf("removed: %s", before)
+	}
+
+	return fmt.Sprintf("changed: %s -> %s", before, after)
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormaliseAddress(t *testing.T) {
+	tt := []struct {
+		address string
+		keep    map[string]bool
+		want    string
+	}{
+		{`aws_s3_bucket.this`, nil, `aws_s3_bucket.this`},
+		{`aws_s3_bucket.this["a"]`, nil, `aws_s3_bucket.this["a"]`},
+		{`module.app["staging"].aws_s3_bucket.this`, nil, `module.app.aws_s3_bucket.this`},
+		{`module.app[0].aws_s3_bucket.this[1]`, nil, `module.app.aws_s3_bucket.this[1]`},
+		{`module.net.module.sub["a"].aws_subnet.x`, nil, `module.net.module.sub.aws_subnet.x`},
+		{`module.net["a"].module.sub["b"].aws_subnet.x["c"]`, nil, `module.net.module.sub.aws_subnet.x["c"]`},
+		{`module.app["a.b[0]"].aws_s3_bucket.this`, nil, `module.app.aws_s3_bucket.this`},
+		{`module.app["a\"].b"].aws_s3_bucket.this`, nil, `module.app.aws_s3_bucket.this`},
+		{`aws_s3_bucket.this["module.app[0]"]`, nil, `aws_s3_bucket.this["module.app[0]"]`},
+		{`data.aws_iam_policy_document.module`, nil, `data.aws_iam_policy_document.module`},
+		{`module.app["x"]`, nil, `module.app`},
+		{`module.app["x"].aws_s3_bucket.this`, map[string]bool{"module.app": true}, `module.app["x"].aws_s3_bucket.this`},
+		{`module.env["a"].module.db["b"].aws_db_instance.x`, map[string]bool{"module.env.module.db": true}, `module.env.module.db["b"].aws_db_instance.x`},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.want, normaliseAddress(tc.address, tc.keep), tc.address)
+	}
+}
+
+func TestComparePlans(t *testing.T) {
+	change := func(address string, actions []string, after map[string]any) PlanResourceChange {
+		return PlanResourceChange{Address: address, Change: PlanChange{Actions: actions, Before: map[string]any{}, After: after}}
+	}
+
+	planA := PlanJSON{ResourceChanges: []PlanResourceChange{
+		change(`module.env["staging"].module.db["main"].aws_db_instance.this`, []string{"create"}, map[string]any{"size": "small"}),
+		change(`module.env["staging"].aws_s3_bucket.logs`, []string{"create"}, map[string]any{"tags": map[string]any{"env": "staging"}}),
+		change(`aws_iam_role.ci`, []string{"update"}, map[string]any{"name": "ci"}),
+		change(`aws_iam_role.unchanged`, []string{"no-op"}, map[string]any{}),
+	}}
+	planB := PlanJSON{ResourceChanges: []PlanResourceChange{
+		change(`module.env["prod"].module.db["main"].aws_db_instance.this`, []string{"create"}, map[string]any{"size": "large"}),
+		change(`module.env["prod"].aws_s3_bucket.logs`, []string{"create"}, map[string]any{"tags": map[string]any{"env": "prod"}}),
+		change(`aws_iam_role.ci`, []string{"delete"}, map[string]any{"name": "ci"}),
+		change(`aws_sns_topic.alerts`, []string{"create"}, map[string]any{}),
+	}}
+
+	results := comparePlans(planA, planB, []string{"tags.*"}, false)
+
+	statuses := map[string]string{}
+	for _, r := range results {
+		statuses[r.Address] = r.Status
+	}
+
+	require.Equal(t, map[string]string{
+		"aws_iam_role.ci":                           "actions_differ",
+		"aws_sns_topic.alerts":                      "only_in_b",
+		"module.env.module.db.aws_db_instance.this": "attributes_differ",
+	}, statuses)
+
+	require.Len(t, comparePlans(planA, planB, []string{"tags.*"}, true), 4)
+}
+
+func TestComparePlansMultipleModuleInstances(t *testing.T) {
+	change := func(address string, size string) PlanResourceChange {
+		return PlanResourceChange{Address: address, Change: PlanChange{Actions: []string{"create"}, Before: map[string]any{}, After: map[string]any{"size": size}}}
+	}
+
+	planA := PlanJSON{ResourceChanges: []PlanResourceChange{
+		change(`module.env["staging"].module.db["main"].aws_db_instance.this`, "small"),
+		change(`module.env["staging"].module.db["replica"].aws_db_instance.this`, "small"),
+	}}
+	planB := PlanJSON{ResourceChanges: []PlanResourceChange{
+		change(`module.env["prod"].module.db["main"].aws_db_instance.this`, "small"),
+		change(`module.env["prod"].module.db["replica"].aws_db_instance.this`, "large"),
+	}}
+
+	require.Equal(t, map[string]bool{"module.env.module.db": true}, multiInstanceModules(planA, planB))
+
+	results := comparePlans(planA, planB, nil, true)
+
+	statuses := map[string]string{}
+	for _, r := range results {
+		statuses[r.Address] = r.Status
+	}
+
+	require.Equal(t, map[string]string{
+		`module.env.module.db["main"].aws_db_instance.this`:    "identical",
+		`module.env.module.db["replica"].aws_db_instance.this`: "attributes_differ",
+	}, statuses)
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Sentinel import names of the mock files contained in a mock bundle
+var sentinelMockImports = map[string]string{
+	"mock-tfconfig.sentinel":    "tfconfig",
+	"mock-tfconfig-v2.sentinel": "tfconfig/v2",
+	"mock-tfplan.sentinel":      "tfplan",
+	"mock-tfplan-v2.sentinel":   "tfplan/v2",
+	"mock-tfrun.sentinel":       "tfrun",
+	"mock-tfstate.sentinel":     "tfstate",
+	"mock-tfstate-v2.sentinel":  "tfstate/v2",
+}
+
+type PlanExport struct {
+	ID         string `json:"id"`
+	PlanID     string `json:"plan_id"`
+	DataType   string `json:"data_type"`
+	Status     string `json:"status"`
+	File       string `json:"file"`
+	UnpackedTo string `json:"unpacked_to"`
+}
+
+var planExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Manage exports of TFE plans",
+	Long:  `Manage exports of TFE plans.`,
+}
+
+var planExportCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Export a plan and download the exported data",
+	Long: `Export a plan, wait for the export to be ready and download the exported data.
+With --unpack-dir, the sentinel mock bundle is unpacked into a directory the Sentinel CLI can use
+with sentinel apply -config <dir>/sentinel-mocks.hcl.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+		dataType, _ := cmd.Flags().GetString("type")
+		out, _ := cmd.Flags().GetString("out")
+		unpackDir, _ := cmd.Flags().GetString("unpack-dir")
+		pollInterval, _ := cmd.Flags().GetDuration("poll-interval")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		if out == "" {
+			out = fmt.Sprintf("%s-%s.tar.gz", id, dataType)
+		}
+
+		planExport, err := createPlanExport(client, id, tfe.PlanExportDataType(dataType), pollInterval, timeout)
+		check(err)
+
+		log.Debugf("Downloading plan export: %s", planExport.ID)
+		data, err := client.PlanExports.Download(context.Background(), planExport.ID)
+		check(err)
+
+		err = os.WriteFile(out, data, 0600)
+		check(err)
+
+		planExport.File = out
+
+		if unpackDir != "" {
+			err = unpackSentinelMocks(data, unpackDir)
+			check(err)
+
+			planExport.UnpackedTo = unpackDir
+		}
+
+		planExportJson, _ := json.MarshalIndent(planExport, "", "  ")
+		outputData(cmd, planExportJson)
+	},
+}
+
+func init() {
+	planCmd.AddCommand(planExportCmd)
+
+	// Create sub-command
+	planExportCmd.AddCommand(planExportCreateCmd)
+	planExportCreateCmd.Flags().String("id", "", "PlanID to export")
+	planExportCreateCmd.Flags().String("type", string(tfe.PlanExportSentinelMockBundleV0), "Type of data to export")
+	planExportCreateCmd.Flags().String("out", "", "File to download the export to, defaults to <planID>-<type>.tar.gz")
+	planExportCreateCmd.Flags().String("unpack-dir", "", "Directory to unpack the sentinel mock bundle into")
+	planExportCreateCmd.Flags().Duration("poll-interval", 2*time.Second, "Interval between export status checks")
+	planExportCreateCmd.Flags().Duration("timeout", 5*time.Minute, "Maximum time to wait for the export to be ready")
+}
+
+// createPlanExport requests the export of the plan and waits until the
+// export is ready to be downloaded.
+func createPlanExport(client *tfe.Client, planID string, dataType tfe.PlanExportDataType, pollInterval time.Duration, timeout time.Duration) (PlanExport, error) {
+	result := PlanExport{
+		PlanID:   planID,
+		DataType: string(dataType),
+	}
+
+	log.Debugf("Exporting plan %s as %s", planID, dataType)
+	pe, err := client.PlanExports.Create(context.Background(), tfe.PlanExportCreateOptions{
+		Plan:     &tfe.Plan{ID: planID},
+		DataType: &dataType,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		log.Debugf("Plan export %s is %s", pe.ID, pe.Status)
+
+		switch pe.Status {
+		case tfe.PlanExportFinished:
+			result.ID = pe.ID
+			result.Status = string(pe.Status)
+			return result, nil
+		case tfe.PlanExportErrored, tfe.PlanExportCanceled, tfe.PlanExportExpired:
+			return result, fmt.Errorf("plan export %s is %s", pe.ID, pe.Status)
+		}
+
+		if time.Now().After(deadline) {
+			return result, fmt.Errorf("plan export %s not ready after %s", pe.ID, timeout)
+		}
+
+		time.Sleep(pollInterval)
+
+		pe, err = client.PlanExports.Read(context.Background(), pe.ID)
+		if err != nil {
+			return result, err
+		}
+	}
+}
+
+// Name of the Sentinel configuration declaring the unpacked mocks. It's kept
+// apart from sentinel.hcl so that the configuration of a policy set isn't overwritten.
+const sentinelMocksConfig = "sentinel-mocks.hcl"
+
+// unpackSentinelMocks extracts the mock bundle into dir/testdata and writes a
+// dir/sentinel-mocks.hcl declaring every mock, for sentinel apply -config.
+func unpackSentinelMocks(bundle []byte, dir string) error {
+	testdata := filepath.Join(dir, "testdata")
+	if err := os.MkdirAll(testdata, 0755); err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		return fmt.Errorf("unable to read plan export: %v", err)
+	}
+	defer gz.Close()
+
+	var mocks []string
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read plan export: %v", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Never write outside of the target directory
+		name := filepath.Base(filepath.Clean(header.Name))
+		target := filepath.Join(testdata, name)
+
+		log.Debugf("Unpacking %s to %s", header.Name, target)
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(f, tr)
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := sentinelMockImports[name]; ok {
+			mocks = append(mocks, name)
+		}
+	}
+
+	sort.Strings(mocks)
+
+	var sb strings.Builder
+	for _, name := range mocks {
+		fmt.Fprintf(&sb, "mock %q {\n  module {\n    source = %q\n  }\n}\n\n", sentinelMockImports[name], filepath.ToSlash(filepath.Join("testdata", name)))
+	}
+
+	return os.WriteFile(filepath.Join(dir, sentinelMocksConfig), []byte(sb.String()), 0644)
+}
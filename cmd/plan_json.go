@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PlanJSON is the subset of the terraform JSON plan representation
+// (terraform show -json) used by the plan sub-commands.
+type PlanJSON struct {
+	FormatVersion    string                  `json:"format_version"`
+	TerraformVersion string                  `json:"terraform_version"`
+	ResourceChanges  []PlanResourceChange    `json:"resource_changes"`
+	OutputChanges    map[string]PlanChange   `json:"output_changes"`
+	Configuration    map[string]any          `json:"configuration"`
+	PlannedValues    map[string]any          `json:"planned_values"`
+	Variables        map[string]PlanVariable `json:"variables"`
+}
+
+type PlanVariable struct {
+	Value any `json:"value"`
+}
+
+type PlanResourceChange struct {
+	Address       string     `json:"address"`
+	ModuleAddress string     `json:"module_address"`
+	Mode          string     `json:"mode"`
+	Type          string     `json:"type"`
+	Name          string     `json:"name"`
+	Index         any        `json:"index"`
+	ProviderName  string     `json:"provider_name"`
+	Change        PlanChange `json:"change"`
+	ActionReason  string     `json:"action_reason"`
+}
+
+type PlanChange struct {
+	Actions         []string `json:"actions"`
+	Before          any      `json:"before"`
+	After           any      `json:"after"`
+	AfterUnknown    any      `json:"after_unknown"`
+	BeforeSensitive any      `json:"before_sensitive"`
+	AfterSensitive  any      `json:"after_sensitive"`
+	ReplacePaths    any      `json:"replace_paths"`
+}
+
+// AttributeChange describes the change of a single attribute, or of a whole
+// block when the block is unchanged.
+type AttributeChange struct {
+	Path      string `json:"path"`
+	Kind      string `json:"kind"`
+	Before    any    `json:"before"`
+	After     any    `json:"after"`
+	Sensitive bool   `json:"sensitive"`
+	Unknown   bool   `json:"unknown"`
+}
+
+const (
+	attributeAdded     = "added"
+	attributeRemoved   = "removed"
+	attributeChanged   = "changed"
+	attributeUnchanged = "unchanged"
+)
+
+// Value shown in place of sensitive values
+const sensitiveValue = "(sensitive value)"
+
+// Value shown in place of values only known after apply
+const unknownValue = "(known after apply)"
+
+func readPlanJSON(client *tfe.Client, planID string) (PlanJSON, error) {
+	var result PlanJSON
+
+	log.Debugf("Retrieving JSON output of plan: %s", planID)
+	planJsonOut, err := client.Plans.ReadJSONOutput(context.Background(), planID)
+	if err != nil {
+		return result, err
+	}
+
+	err = json.Unmarshal(planJsonOut, &result)
+	if err != nil {
+		return result, fmt.Errorf("unable to parse JSON output of plan %s: %v", planID, err)
+	}
+
+	return result, nil
+}
+
+// Action returns a single word describing the actions of the change.
+func (c PlanChange) Action() string {
+	switch len(c.Actions) {
+	case 0:
+		return "no-op"
+	case 1:
+		return c.Actions[0]
+	}
+
+	return "replace"
+}
+
+// Module returns the module address of the resource, or root for resources
+// of the root module.
+func (r PlanResourceChange) Module() string {
+	if r.ModuleAddress == "" {
+		return "root"
+	}
+
+	return r.ModuleAddress
+}
+
+// AttributeChanges compares the before and after values of the change and
+// returns every added, removed, changed and unchanged attribute.
+func (c PlanChange) AttributeChanges() []AttributeChange {
+	var results []AttributeChange
+
+	diffAttributes("", c.Before, c.After, c.AfterUnknown, c.BeforeSensitive, c.AfterSensitive, &results)
+
+	return results
+}
+
+func diffAttributes(path string, before any, after any, unknown any, beforeSensitive any, afterSensitive any, results *[]AttributeChange) {
+	isUnknown := unknown == true
+	isSensitive := beforeSensitive == true || afterSensitive == true
+
+	beforeMap, beforeIsMap := before.(map[string]any)
+	afterMap, afterIsMap := after.(map[string]any)
+	beforeList, beforeIsList := before.([]any)
+	afterList, afterIsList := after.([]any)
+
+	switch {
+	case isUnknown || isSensitive:
+		// Leaf values, their content is not shown
+	case (beforeIsMap || before == nil) && (afterIsMap || after == nil) && (beforeIsMap || afterIsMap):
+		if path != "" && reflect.DeepEqual(before, after) && !containsTrue(unknown) {
+			*results = append(*results, AttributeChange{Path: path, Kind: attributeUnchanged, Before: before, After: after})
+			return
+		}
+
+		keys := map[string]bool{}
+		for k := range beforeMap {
+			keys[k] = true
+		}
+		for k := range afterMap {
+			keys[k] = true
+		}
+		for k := range asMap(unknown) {
+			keys[k] = true
+		}
+
+		var sortedKeys []string
+		for k := range keys {
+			sortedKeys = append(sortedKeys, k)
+		}
+		sort.Strings(sortedKeys)
+
+		for _, k := range sortedKeys {
+			diffAttributes(joinAttributePath(path, k), beforeMap[k], afterMap[k], childValue(unknown, k), childValue(beforeSensitive, k), childValue(afterSensitive, k), results)
+		}
+		return
+	case (beforeIsList || before == nil) && (afterIsList || after == nil) && (beforeIsList || afterIsList):
+		if path != "" && reflect.DeepEqual(before, after) && !containsTrue(unknown) {
+			*results = append(*results, AttributeChange{Path: path, Kind: attributeUnchanged, Before: before, After: after})
+			return
+		}
+
+		length := len(beforeList)
+		if len(afterList) > length {
+			length = len(afterList)
+		}
+		if unknownList, ok := unknown.([]any); ok && len(unknownList) > length {
+			length = len(unknownList)
+		}
+
+		for i := 0; i < length; i++ {
+			var b, a any
+			if i < len(beforeList) {
+				b = beforeList[i]
+			}
+			if i < len(afterList) {
+				a = afterList[i]
+			}
+			diffAttributes(fmt.Sprintf("%s[%d]", path, i), b, a, childValue(unknown, i), childValue(beforeSensitive, i), childValue(afterSensitive, i), results)
+		}
+		return
+	}
+
+	change := AttributeChange{
+		Path:      path,
+		Before:    before,
+		After:     after,
+		Sensitive: isSensitive,
+		Unknown:   isUnknown,
+	}
+
+	switch {
+	case isUnknown && before == nil:
+		change.Kind = attributeAdded
+	case isUnknown:
+		change.Kind = attributeChanged
+	case before == nil && after == nil:
+		return
+	case before == nil:
+		change.Kind = attributeAdded
+	case after == nil:
+		change.Kind = attributeRemoved
+	case reflect.DeepEqual(before, after):
+		change.Kind = attributeUnchanged
+	default:
+		change.Kind = attributeChanged
+	}
+
+	*results = append(*results, change)
+}
+
+// childValue returns the element of a nested unknown/sensitive marker, a
+// marker set to true applies to everything below it.
+func childValue(marker any, key any) any {
+	switch m := marker.(type) {
+	case bool:
+		return m
+	case map[string]any:
+		if k, ok := key.(string); ok {
+			return m[k]
+		}
+	case []any:
+		if i, ok := key.(int); ok && i < len(m) {
+			return m[i]
+		}
+	}
+
+	return nil
+}
+
+func containsTrue(marker any) bool {
+	switch m := marker.(type) {
+	case bool:
+		return m
+	case map[string]any:
+		for _, v := range m {
+			if containsTrue(v) {
+				return true
+			}
+		}
+	case []any:
+		for _, v := range m {
+			if containsTrue(v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func asMap(value any) map[string]any {
+	m, _ := value.(map[string]any)
+	return m
+}
+
+func joinAttributePath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// formatAttributeValue renders a value for display, masking sensitive values.
+func formatAttributeValue(value any, sensitive bool, unknown bool) string {
+	if unknown {
+		return unknownValue
+	}
+	if sensitive {
+		return sensitiveValue
+	}
+	if value == nil {
+		return "null"
+	}
+
+	var buffer bytes.Buffer
+	var jsonEnc = json.NewEncoder(&buffer)
+
+	// Keep "<", ">" and "&" readable
+	jsonEnc.SetEscapeHTML(false)
+
+	if err := jsonEnc.Encode(value); err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
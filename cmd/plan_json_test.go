@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributeChanges(t *testing.T) {
+	tt := []struct {
+		name   string
+		change string
+		want   []AttributeChange
+	}{
+		{
+			name:   "added attribute",
+			change: `{"before": {}, "after": {"name": "a"}}`,
+			want: []AttributeChange{
+				{Path: "name", Kind: attributeAdded, After: "a"},
+			},
+		},
+		{
+			name:   "removed attribute",
+			change: `{"before": {"name": "a"}, "after": {}}`,
+			want: []AttributeChange{
+				{Path: "name", Kind: attributeRemoved, Before: "a"},
+			},
+		},
+		{
+			name:   "changed and unchanged attributes",
+			change: `{"before": {"name": "a", "size": 1}, "after": {"name": "b", "size": 1}}`,
+			want: []AttributeChange{
+				{Path: "name", Kind: attributeChanged, Before: "a", After: "b"},
+				{Path: "size", Kind: attributeUnchanged, Before: float64(1), After: float64(1)},
+			},
+		},
+		{
+			name:   "resource created",
+			change: `{"before": null, "after": {"name": "a"}}`,
+			want: []AttributeChange{
+				{Path: "name", Kind: attributeAdded, After: "a"},
+			},
+		},
+		{
+			name:   "null attributes are skipped",
+			change: `{"before": {"name": null}, "after": {"name": null}}`,
+			want:   nil,
+		},
+		{
+			name:   "nested block change",
+			change: `{"before": {"tags": {"env": "dev", "team": "x"}}, "after": {"tags": {"env": "prd", "team": "x"}}}`,
+			want: []AttributeChange{
+				{Path: "tags.env", Kind: attributeChanged, Before: "dev", After: "prd"},
+				{Path: "tags.team", Kind: attributeUnchanged, Before: "x", After: "x"},
+			},
+		},
+		{
+			name:   "unchanged block is collapsed",
+			change: `{"before": {"tags": {"env": "dev"}}, "after": {"tags": {"env": "dev"}}}`,
+			want: []AttributeChange{
+				{Path: "tags", Kind: attributeUnchanged, Before: map[string]any{"env": "dev"}, After: map[string]any{"env": "dev"}},
+			},
+		},
+		{
+			name:   "list elements",
+			change: `{"before": {"ports": [80, 443]}, "after": {"ports": [80]}}`,
+			want: []AttributeChange{
+				{Path: "ports[0]", Kind: attributeUnchanged, Before: float64(80), After: float64(80)},
+				{Path: "ports[1]", Kind: attributeRemoved, Before: float64(443)},
+			},
+		},
+		{
+			name:   "unknown after apply",
+			change: `{"before": {"id": "i-1"}, "after": {}, "after_unknown": {"id": true, "arn": true}}`,
+			want: []AttributeChange{
+				{Path: "arn", Kind: attributeAdded, Unknown: true},
+				{Path: "id", Kind: attributeChanged, Before: "i-1", Unknown: true},
+			},
+		},
+		{
+			name:   "sensitive attribute",
+			change: `{"before": {"password": "a"}, "after": {"password": "b"}, "after_sensitive": {"password": true}}`,
+			want: []AttributeChange{
+				{Path: "password", Kind: attributeChanged, Before: "a", After: "b", Sensitive: true},
+			},
+		},
+		{
+			name:   "sensitive block is a leaf",
+			change: `{"before": {"secret": {"a": "1"}}, "after": {"secret": {"a": "2"}}, "before_sensitive": {"secret": true}}`,
+			want: []AttributeChange{
+				{Path: "secret", Kind: attributeChanged, Before: map[string]any{"a": "1"}, After: map[string]any{"a": "2"}, Sensitive: true},
+			},
+		},
+		{
+			name:   "sensitive list element",
+			change: `{"before": {"keys": ["a", "b"]}, "after": {"keys": ["a", "c"]}, "after_sensitive": {"keys": [false, true]}}`,
+			want: []AttributeChange{
+				{Path: "keys[0]", Kind: attributeUnchanged, Before: "a", After: "a"},
+				{Path: "keys[1]", Kind: attributeChanged, Before: "b", After: "c", Sensitive: true},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var change PlanChange
+			require.NoError(t, json.Unmarshal([]byte(tc.change), &change))
+
+			require.Equal(t, tc.want, change.AttributeChanges())
+		})
+	}
+}
+
+func TestFormatAttributeValue(t *testing.T) {
+	tt := []struct {
+		value     any
+		sensitive bool
+		unknown   bool
+		want      string
+	}{
+		{"a<b>", false, false, `"a<b>"`},
+		{nil, false, false, "null"},
+		{float64(1), false, false, "1"},
+		{map[string]any{"a": true}, false, false, `{"a":true}`},
+		{"secret", true, false, sensitiveValue},
+		{nil, false, true, unknownValue},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.want, formatAttributeValue(tc.value, tc.sensitive, tc.unknown))
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Order in which actions are rendered
+var planRenderActions = []struct {
+	action string
+	title  string
+	symbol string
+}{
+	{"create", "Create", "+"},
+	{"update", "Update in-place", "~"},
+	{"replace", "Replace", "-/+"},
+	{"delete", "Destroy", "-"},
+	{"read", "Read", "<="},
+}
+
+var planRenderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Render the changes of a plan in a human-readable format",
+	Long: `Render the changes of a plan in a human-readable format.
+The markdown format is suitable to be posted as a pull-request comment.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+		format, _ := cmd.Flags().GetString("format")
+
+		if format != "markdown" && format != "text" {
+			log.Fatalf("unsupported format %s, use one of markdown or text", format)
+		}
+
+		plan, err := readPlanJSON(client, id)
+		check(err)
+
+		cmd.Println(renderPlan(id, plan, format))
+	},
+}
+
+func init() {
+	planCmd.AddCommand(planRenderCmd)
+
+	planRenderCmd.Flags().String("id", "", "PlanID to render")
+	planRenderCmd.Flags().String("format", "markdown", "Render format. Supported values are markdown or text")
+}
+
+func renderPlan(planID string, plan PlanJSON, format string) string {
+	var sb strings.Builder
+	markdown := format == "markdown"
+
+	// Group resource changes by action then by module
+	grouped := map[string]map[string][]PlanResourceChange{}
+	counts := map[string]int{}
+
+	for _, rc := range plan.ResourceChanges {
+		action := rc.Change.Action()
+		if action == "no-op" {
+			continue
+		}
+
+		if grouped[action] == nil {
+			grouped[action] = map[string][]PlanResourceChange{}
+		}
+		grouped[action][rc.Module()] = append(grouped[action][rc.Module()], rc)
+		counts[action]++
+	}
+
+	summary := fmt.Sprintf("%d to add, %d to change, %d to replace, %d to destroy, %d to read",
+		counts["create"], counts["update"], counts["replace"], counts["delete"], counts["read"])
+
+	if markdown {
+		fmt.Fprintf(&sb, "## Plan `%s`\n\n**%s**\n", planID, summary)
+	} else {
+		fmt.Fprintf(&sb, "Plan %s: %s\n", planID, summary)
+	}
+
+	if len(grouped) == 0 {
+		if markdown {
+			sb.WriteString("\nNo changes. Your infrastructure matches the configuration.\n")
+		} else {
+			sb.WriteString("No changes. Your infrastructure matches the configuration.\n")
+		}
+		return sb.String()
+	}
+
+	for _, a := range planRenderActions {
+		modules, ok := grouped[a.action]
+		if !ok {
+			continue
+		}
+
+		var moduleNames []string
+		for m := range modules {
+			moduleNames = append(moduleNames, m)
+		}
+		sort.Strings(moduleNames)
+
+		if markdown {
+			fmt.Fprintf(&sb, "\n### %s (%d)\n", a.title, counts[a.action])
+		} else {
+			fmt.Fprintf(&sb, "\n%s (%d)\n", a.title, counts[a.action])
+		}
+
+		for _, m := range moduleNames {
+			if markdown {
+				fmt.Fprintf(&sb, "\n#### Module: `%s`\n\n", m)
+			} else {
+				fmt.Fprintf(&sb, "\n  Module: %s\n", m)
+			}
+
+			for _, rc := range modules[m] {
+				renderResourceChange(&sb, rc, a.symbol, markdown)
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+func renderResourceChange(sb *strings.Builder, rc PlanResourceChange, symbol string, markdown bool) {
+	var lines []string
+	unchanged := 0
+
+	for _, ac := range rc.Change.AttributeChanges() {
+		switch ac.Kind {
+		case attributeAdded:
+			lines = append(lines, fmt.Sprintf("+ %s = %s", ac.Path, formatAttributeValue(ac.After, ac.Sensitive, ac.Unknown)))
+		case attributeRemoved:
+			lines = append(lines, fmt.Sprintf("- %s = %s", ac.Path, formatAttributeValue(ac.Before, ac.Sensitive, false)))
+		case attributeChanged:
+			lines = append(lines, fmt.Sprintf("! %s = %s -> %s", ac.Path,
+				formatAttributeValue(ac.Before, ac.Sensitive, false),
+				formatAttributeValue(ac.After, ac.Sensitive, ac.Unknown)))
+		default:
+			unchanged++
+		}
+	}
+
+	if unchanged > 0 {
+		lines = append(lines, fmt.Sprintf("# (%d unchanged attributes hidden)", unchanged))
+	}
+
+	title := rc.Address
+	if rc.ActionReason != "" {
+		title = fmt.Sprintf("%s (%s)", rc.Address, strings.ReplaceAll(rc.ActionReason, "_", " "))
+	}
+
+	if markdown {
+		fmt.Fprintf(sb, "<details><summary><code>%s %s</code></summary>\n\n```diff\n", html.EscapeString(symbol), html.EscapeString(title))
+		for _, line := range lines {
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString("```\n\n</details>\n")
+		return
+	}
+
+	fmt.Fprintf(sb, "    %s %s\n", symbol, title)
+	for _, line := range lines {
+		fmt.Fprintf(sb, "        %s\n", line)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/AGLEnergyPublic/tfectl/resources"
 	tfe "github.com/hashicorp/go-tfe"
@@ -68,12 +71,140 @@ var policyListCmd = &cobra.Command{
 	},
 }
 
+var policyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a TFE policy",
+	Long: `Create a TFE policy and upload its content from a local file.
+The name of the policy defaults to the name of the file without its extension.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		name, _ := cmd.Flags().GetString("name")
+		file, _ := cmd.Flags().GetString("file")
+		enforce, _ := cmd.Flags().GetString("enforce")
+		kind, _ := cmd.Flags().GetString("kind")
+		query, _ := cmd.Flags().GetString("query")
+		description, _ := cmd.Flags().GetString("description")
+
+		if file == "" {
+			log.Fatal("please provide the file containing the policy to perform this operation!")
+		}
+
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+
+		content, err := os.ReadFile(file)
+		check(err)
+
+		enforcementLevel := tfe.EnforcementLevel(enforce)
+		options := tfe.PolicyCreateOptions{
+			Name:             &name,
+			Kind:             tfe.PolicyKind(kind),
+			Description:      &description,
+			EnforcementLevel: &enforcementLevel,
+		}
+
+		if query != "" {
+			options.Query = &query
+		}
+
+		policy, err := createPolicy(client, organization, options, content)
+		check(err)
+
+		policyJson, _ := json.MarshalIndent(policy, "", "  ")
+		outputData(cmd, policyJson)
+	},
+}
+
+var policyUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update a TFE policy",
+	Long: `Update a TFE policy.
+Only the given flags are updated, the content of the policy is uploaded again when --file is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+		file, _ := cmd.Flags().GetString("file")
+		enforce, _ := cmd.Flags().GetString("enforce")
+		query, _ := cmd.Flags().GetString("query")
+		description, _ := cmd.Flags().GetString("description")
+
+		options := tfe.PolicyUpdateOptions{}
+
+		if cmd.Flags().Changed("enforce") {
+			enforcementLevel := tfe.EnforcementLevel(enforce)
+			options.EnforcementLevel = &enforcementLevel
+		}
+		if cmd.Flags().Changed("query") {
+			options.Query = &query
+		}
+		if cmd.Flags().Changed("description") {
+			options.Description = &description
+		}
+
+		var content []byte
+		if file != "" {
+			content, err = os.ReadFile(file)
+			check(err)
+		}
+
+		policy, err := updatePolicy(client, id, options, content)
+		check(err)
+
+		policyJson, _ := json.MarshalIndent(policy, "", "  ")
+		outputData(cmd, policyJson)
+	},
+}
+
+var policyDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a TFE policy",
+	Long:  `Delete a TFE policy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+
+		log.Debugf("Deleting policy: %s", id)
+		err = client.Policies.Delete(context.Background(), id)
+		check(err)
+
+		log.Infof("Deleted policy %s", id)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(policyCmd)
 
 	// List sub-command
 	policyCmd.AddCommand(policyListCmd)
 	policyListCmd.Flags().String("filter", "", "Search for policy by name")
+
+	// Create sub-command
+	policyCmd.AddCommand(policyCreateCmd)
+	policyCreateCmd.Flags().String("name", "", "Name of the policy, defaults to the name of the file")
+	policyCreateCmd.Flags().String("file", "", "File containing the policy")
+	policyCreateCmd.Flags().String("enforce", string(tfe.EnforcementSoft), "Enforcement level: advisory, soft-mandatory, hard-mandatory or mandatory (OPA)")
+	policyCreateCmd.Flags().String("kind", string(tfe.Sentinel), "Kind of policy: sentinel or opa")
+	policyCreateCmd.Flags().String("query", "", "Query of an OPA policy, e.g. data.terraform.deny")
+	policyCreateCmd.Flags().String("description", "Policy Created by tfectl", "Description for the policy")
+
+	// Update sub-command
+	policyCmd.AddCommand(policyUpdateCmd)
+	policyUpdateCmd.Flags().String("id", "", "ID of the policy")
+	policyUpdateCmd.Flags().String("file", "", "File containing the policy")
+	policyUpdateCmd.Flags().String("enforce", "", "Enforcement level: advisory, soft-mandatory, hard-mandatory or mandatory (OPA)")
+	policyUpdateCmd.Flags().String("query", "", "Query of an OPA policy, e.g. data.terraform.deny")
+	policyUpdateCmd.Flags().String("description", "", "Description for the policy")
+
+	// Delete sub-command
+	policyCmd.AddCommand(policyDeleteCmd)
+	policyDeleteCmd.Flags().String("id", "", "ID of the policy")
 }
 
 func listPolicies(client *tfe.Client, organization string, filter string) ([]*tfe.Policy, error) {
@@ -105,3 +236,57 @@ func listPolicies(client *tfe.Client, organization string, filter string) ([]*tf
 
 	return results, nil
 }
+
+func createPolicy(client *tfe.Client, organization string, options tfe.PolicyCreateOptions, content []byte) (Policy, error) {
+	log.Debugf("Creating policy: %s", *options.Name)
+	p, err := client.Policies.Create(context.Background(), organization, options)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	log.Debugf("Uploading content of policy: %s", p.ID)
+	err = client.Policies.Upload(context.Background(), p.ID, content)
+	if err != nil {
+		// Don't leave a policy without content behind
+		log.Debugf("Deleting policy %s after the failed upload", p.ID)
+		if delErr := client.Policies.Delete(context.Background(), p.ID); delErr != nil {
+			return Policy{}, fmt.Errorf("unable to upload the content of policy %s: %v, the empty policy couldn't be deleted: %v", p.ID, err, delErr)
+		}
+		return Policy{}, fmt.Errorf("unable to upload the content of policy %s, it was deleted: %v", p.ID, err)
+	}
+
+	return newPolicy(p), nil
+}
+
+func updatePolicy(client *tfe.Client, policyID string, options tfe.PolicyUpdateOptions, content []byte) (Policy, error) {
+	log.Debugf("Updating policy: %s", policyID)
+	p, err := client.Policies.Update(context.Background(), policyID, options)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	if content != nil {
+		log.Debugf("Uploading content of policy: %s", policyID)
+		err = client.Policies.Upload(context.Background(), policyID, content)
+		if err != nil {
+			return Policy{}, err
+		}
+	}
+
+	return newPolicy(p), nil
+}
+
+func newPolicy(p *tfe.Policy) Policy {
+	enforce := string(p.EnforcementLevel)
+	if enforce == "" && len(p.Enforce) > 0 {
+		enforce = string(p.Enforce[0].Mode)
+	}
+
+	return Policy{
+		ID:             p.ID,
+		Name:           p.Name,
+		Kind:           string(p.Kind),
+		Enforce:        enforce,
+		PolicySetCount: p.PolicySetCount,
+	}
+}
@@ -3,6 +3,10 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/AGLEnergyPublic/tfectl/resources"
 	"github.com/hashicorp/go-tfe"
@@ -22,11 +26,80 @@ type PolicyCheckResult struct {
 	Sentinel       any  `json:"sentinel"`
 }
 
+type PolicyCheckPolicy struct {
+	Name             string `json:"name"`
+	EnforcementLevel string `json:"enforcement_level"`
+	Passed           bool   `json:"passed"`
+	Description      string `json:"description"`
+	Error            string `json:"error"`
+	Trace            string `json:"trace"`
+}
+
+type PolicyCheckPolicySet struct {
+	Name     string              `json:"name"`
+	Result   bool                `json:"result"`
+	Error    string              `json:"error"`
+	Policies []PolicyCheckPolicy `json:"policies"`
+}
+
+// PolicyCheck is either a Sentinel policy check or an OPA policy evaluation
+// of a run, both broken down per policy set and per policy.
 type PolicyCheck struct {
-	ID     string            `json:"id"`
-	Result PolicyCheckResult `json:"result"`
-	Status tfe.PolicyStatus  `json:"status"`
-	Scope  tfe.PolicyScope   `json:"scope"`
+	ID         string                 `json:"id"`
+	Kind       string                 `json:"kind"`
+	Result     PolicyCheckResult      `json:"result"`
+	Status     string                 `json:"status"`
+	Scope      string                 `json:"scope"`
+	PolicySets []PolicyCheckPolicySet `json:"policy_sets"`
+}
+
+// PolicyOverride is the result of overriding the soft-failed policy check of a run
+type PolicyOverride struct {
+	RunID string `json:"run_id"`
+	PolicyCheck
+	Error string `json:"error"`
+}
+
+type PendingOverride struct {
+	RunID          string   `json:"run_id"`
+	WorkspaceID    string   `json:"workspace_id"`
+	WorkspaceName  string   `json:"workspace_name"`
+	CreatedAt      string   `json:"created_at"`
+	PolicyCheckID  string   `json:"policy_check_id"`
+	FailedPolicies []string `json:"failed_policies"`
+}
+
+// Sentinel result data of a policy check
+type sentinelResult struct {
+	Data map[string]sentinelPolicySetResult `json:"data"`
+}
+
+type sentinelPolicySetResult struct {
+	Error    any                    `json:"error"`
+	Result   bool                   `json:"result"`
+	Policies []sentinelPolicyResult `json:"policies"`
+}
+
+type sentinelPolicyResult struct {
+	AllowedFailure bool            `json:"allowed-failure"`
+	Error          any             `json:"error"`
+	Policy         json.RawMessage `json:"policy"`
+	Result         bool            `json:"result"`
+	Trace          *sentinelTrace  `json:"trace"`
+}
+
+type sentinelTrace struct {
+	Description string                  `json:"description"`
+	Error       any                     `json:"error"`
+	Print       string                  `json:"print"`
+	Result      bool                    `json:"result"`
+	Rules       map[string]sentinelRule `json:"rules"`
+}
+
+type sentinelRule struct {
+	Desc  string `json:"desc"`
+	Ident string `json:"ident"`
+	Value any    `json:"value"`
 }
 
 var policyCheckCmd = &cobra.Command{
@@ -37,20 +110,28 @@ var policyCheckCmd = &cobra.Command{
 
 var policyCheckShowCmd = &cobra.Command{
 	Use:   "show",
-	Short: "Show details of the policy check in a TFE run",
-	Long:  `Show details of the policy check in a TFE run`,
+	Short: "Show details of the policy checks in a TFE run",
+	Long: `Show details of the Sentinel policy checks and OPA policy evaluations in a TFE run.
+With --trace, the Sentinel trace of every policy is printed in readable form.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// policy check show function
 		_, client, err := resources.Setup(cmd)
 		check(err)
 
 		runId, _ := cmd.Flags().GetString("run-id")
+		trace, _ := cmd.Flags().GetBool("trace")
 
 		var policyCheckJson []byte
 
-		policyCheck, _ := showPolicyChecks(client, runId)
+		policyChecks, err := showPolicyChecks(client, runId)
+		check(err)
+
+		if trace {
+			cmd.Print(renderPolicyTraces(policyChecks))
+			return
+		}
 
-		policyCheckJson, _ = json.MarshalIndent(policyCheck, "", "  ")
+		policyCheckJson, _ = json.MarshalIndent(policyChecks, "", "  ")
 		outputData(cmd, policyCheckJson)
 	},
 }
@@ -58,23 +139,102 @@ var policyCheckShowCmd = &cobra.Command{
 var policyCheckOverrideCmd = &cobra.Command{
 	Use:   "override",
 	Short: "Override the policy check for a given TFE run",
-	Long:  `Override the policy  check for a given TFE run.`,
+	Long: `Override the policy  check for a given TFE run.
+The reason of the override is posted as a comment on the run before the override,
+a policy check is only overridden once its reason is recorded.
+With --run-ids, the soft-failed policy check of each run is overridden. A failure on one run
+doesn't stop the others, the result of each run is reported.`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		_, client, err := resources.Setup(cmd)
 		check(err)
 
 		policyCheckId, _ := cmd.Flags().GetString("policy-check-id")
+		runIds, _ := cmd.Flags().GetString("run-ids")
+		reason, _ := cmd.Flags().GetString("reason")
+
+		if policyCheckId != "" && runIds != "" {
+			log.Fatal("policy-check-id and run-ids are mutually exclusive, use one or the other!")
+		}
+
+		if policyCheckId == "" && runIds == "" {
+			log.Fatal("please provide one of policy-check-id or run-ids to perform this operation!")
+		}
+
+		if strings.TrimSpace(reason) == "" {
+			log.Fatal("please provide the reason for the override to perform this operation!")
+		}
 
 		var policyCheckJson []byte
 
-		policyCheck, _ := overridePolicyChecks(client, policyCheckId)
+		if policyCheckId != "" {
+			policyCheck, err := overridePolicyChecks(client, policyCheckId, reason)
+			check(err)
+
+			policyCheckJson, _ = json.MarshalIndent(policyCheck, "", "  ")
+			outputData(cmd, policyCheckJson)
+			return
+		}
+
+		ids := splitIDs(runIds)
+		if len(ids) == 0 {
+			log.Fatal("please provide at least one run ID in run-ids to perform this operation!")
+		}
+
+		overrides := []PolicyOverride{}
+		failed := 0
+
+		for _, runId := range ids {
+			softFailedId, err := findSoftFailedPolicyCheck(client, runId)
+			if err != nil {
+				overrides = append(overrides, PolicyOverride{RunID: runId, Error: err.Error()})
+				failed++
+				continue
+			}
+
+			if softFailedId == "" {
+				log.Warnf("Run %s has no overridable soft-failed policy check", runId)
+				continue
+			}
+
+			policyCheck, err := overridePolicyChecks(client, softFailedId, reason)
+			override := PolicyOverride{RunID: runId, PolicyCheck: policyCheck}
+			if err != nil {
+				override.ID = softFailedId
+				override.Error = err.Error()
+				failed++
+			}
+
+			overrides = append(overrides, override)
+		}
+
+		if failed > 0 {
+			log.Warnf("%d of %d runs could not be overridden", failed, len(ids))
+		}
 
-		policyCheckJson, _ = json.MarshalIndent(policyCheck, "", "  ")
+		policyCheckJson, _ = json.MarshalIndent(overrides, "", "  ")
 		outputData(cmd, policyCheckJson)
 	},
 }
 
+var policyCheckPendingCmd = &cobra.Command{
+	Use:   "pending",
+	Short: "List runs awaiting a policy override",
+	Long:  `List runs awaiting a policy override across the workspaces of the organization.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		filter, _ := cmd.Flags().GetString("filter")
+
+		pendingOverrides, err := listPendingOverrides(client, organization, filter)
+		check(err)
+
+		pendingOverridesJson, _ := json.MarshalIndent(pendingOverrides, "", "  ")
+		outputData(cmd, pendingOverridesJson)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(policyCheckCmd)
 
@@ -82,47 +242,173 @@ func init() {
 	// Returns the detailed policy check results for a given list of RunIDs
 	policyCheckCmd.AddCommand(policyCheckShowCmd)
 	policyCheckShowCmd.Flags().String("run-id", "", "RunId to inspect")
+	policyCheckShowCmd.Flags().Bool("trace", false, "Print the Sentinel trace of every policy instead of the JSON output")
 
 	// Override sub-command
 	// Overrides a given policy check
 	policyCheckCmd.AddCommand(policyCheckOverrideCmd)
-	policyCheckOverrideCmd.Flags().String("policy-check-id", "", "ID of the policy-check to override")
+	policyCheckOverrideCmd.Flags().String("policy-check-id", "", "ID of the policy-check to override")                                 // Mutually exclusive with `run-ids`
+	policyCheckOverrideCmd.Flags().String("run-ids", "", "Comma-separated list of RunIDs to override the soft-failed policy check of") // Mutually exclusive with `policy-check-id`
+	policyCheckOverrideCmd.Flags().String("reason", "", "Justification of the override, posted as a comment on the run")
+
+	// Pending sub-command
+	// Lists runs awaiting a policy override
+	policyCheckCmd.AddCommand(policyCheckPendingCmd)
+	policyCheckPendingCmd.Flags().String("filter", "", "Only search workspaces matching filter")
 }
 
-func showPolicyChecks(client *tfe.Client, runID string) (PolicyCheck, error) {
-	result := PolicyCheck{}
-	log.Debugf("Retrieving policy checks for run: %s\n", runID)
-	options := &tfe.PolicyCheckListOptions{}
+func showPolicyChecks(client *tfe.Client, runID string) ([]PolicyCheck, error) {
+	results := []PolicyCheck{}
 
-	pc, err := client.PolicyChecks.List(context.Background(), runID, options)
-	check(err)
+	policyChecks, err := listRunPolicyChecks(client, runID)
+	if err != nil {
+		return nil, err
+	}
 
-	polchk := pc.Items[0]
+	for _, polchk := range policyChecks {
+		results = append(results, newPolicyCheck(polchk))
+	}
 
-	result.ID = polchk.ID
-	result.Scope = polchk.Scope
-	result.Status = polchk.Status
-	result.Result.AdvisoryFailed = polchk.Result.AdvisoryFailed
-	result.Result.HardFailed = polchk.Result.HardFailed
-	result.Result.TotalFailed = polchk.Result.TotalFailed
-	result.Result.SoftFailed = polchk.Result.SoftFailed
-	result.Result.Passed = polchk.Result.Passed
-	result.Result.Sentinel = polchk.Result.Sentinel
-	result.Result.Result = polchk.Result.Result
+	// OPA policies are evaluated in the task stages of the run. Failing to
+	// read them doesn't hide the Sentinel policy checks.
+	taskStages, err := listRunTaskStages(client, runID)
+	if err != nil {
+		log.Warnf("Unable to retrieve the task stages of run %s, OPA policy evaluations are not shown: %v", runID, err)
+		return results, nil
+	}
 
-	return result, nil
+	for _, ts := range taskStages {
+		log.Debugf("Retrieving policy evaluations for task stage: %s\n", ts.ID)
+		pe, err := client.PolicyEvaluations.List(context.Background(), ts.ID, &tfe.PolicyEvaluationListOptions{})
+		if err != nil {
+			log.Warnf("Unable to retrieve the policy evaluations of task stage %s: %v", ts.ID, err)
+			continue
+		}
+
+		for _, evaluation := range pe.Items {
+			policyCheck, err := newPolicyEvaluationCheck(client, evaluation)
+			if err != nil {
+				log.Warnf("Unable to retrieve the policy set outcomes of policy evaluation %s, only its summary is shown: %v", evaluation.ID, err)
+			}
+
+			results = append(results, policyCheck)
+		}
+	}
+
+	return results, nil
 }
 
-func overridePolicyChecks(client *tfe.Client, policyCheckID string) (PolicyCheck, error) {
-	result := PolicyCheck{}
-	log.Debugf("Overriding policy check: %s\n", policyCheckID)
+// overridePolicyChecks records the reason of the override as a comment on the
+// run of the policy check, then overrides it. The policy check isn't
+// overridden when the reason can't be recorded.
+func overridePolicyChecks(client *tfe.Client, policyCheckID string, reason string) (PolicyCheck, error) {
+	log.Debugf("Reading policy check: %s\n", policyCheckID)
+	p, err := client.PolicyChecks.Read(context.Background(), policyCheckID)
+	if err != nil {
+		return PolicyCheck{}, err
+	}
+
+	if p.Run == nil || p.Run.ID == "" {
+		return PolicyCheck{}, fmt.Errorf("run of policy check %s not found, it wasn't overridden as the reason can't be recorded", policyCheckID)
+	}
+
+	_, err = addRunComment(client, p.Run.ID, fmt.Sprintf("Policy check %s overridden: %s", policyCheckID, reason))
+	if err != nil {
+		return PolicyCheck{}, fmt.Errorf("unable to record the reason on run %s, policy check %s wasn't overridden: %v", p.Run.ID, policyCheckID, err)
+	}
 
+	log.Debugf("Overriding policy check: %s\n", policyCheckID)
 	polchk, err := client.PolicyChecks.Override(context.Background(), policyCheckID)
-	check(err)
+	if err != nil {
+		return PolicyCheck{}, fmt.Errorf("the reason was recorded on run %s but policy check %s couldn't be overridden: %v", p.Run.ID, policyCheckID, err)
+	}
+
+	return newPolicyCheck(polchk), nil
+}
+
+// findSoftFailedPolicyCheck returns the ID of the overridable soft-failed
+// policy check of the run, or an empty string when there is none.
+func findSoftFailedPolicyCheck(client *tfe.Client, runID string) (string, error) {
+	policyChecks, err := listRunPolicyChecks(client, runID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, polchk := range policyChecks {
+		if polchk.Status == tfe.PolicySoftFailed && polchk.Actions != nil && polchk.Actions.IsOverridable {
+			return polchk.ID, nil
+		}
+	}
+
+	return "", nil
+}
+
+func listPendingOverrides(client *tfe.Client, organization string, filter string) ([]PendingOverride, error) {
+	results := []PendingOverride{}
+
+	workspaces, err := listWorkspaces(client, organization, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, workspace := range workspaces {
+		log.Debugf("Processing workspace: %s - %s", workspace.Name, workspace.ID)
+
+		runs, err := listRuns(client, workspace.ID, string(tfe.RunPolicyOverride), "", true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, run := range runs {
+			pending := PendingOverride{
+				RunID:          run.ID,
+				WorkspaceID:    workspace.ID,
+				WorkspaceName:  workspace.Name,
+				CreatedAt:      run.CreatedAt.Format(time.RFC3339),
+				FailedPolicies: []string{},
+			}
+
+			policyChecks, err := listRunPolicyChecks(client, run.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, polchk := range policyChecks {
+				if polchk.Status != tfe.PolicySoftFailed {
+					continue
+				}
+
+				pending.PolicyCheckID = polchk.ID
+
+				for _, ps := range newPolicyCheck(polchk).PolicySets {
+					for _, p := range ps.Policies {
+						if !p.Passed && p.EnforcementLevel != string(tfe.EnforcementAdvisory) {
+							pending.FailedPolicies = append(pending.FailedPolicies, p.Name)
+						}
+					}
+				}
+			}
+
+			results = append(results, pending)
+		}
+	}
+
+	return results, nil
+}
+
+func newPolicyCheck(polchk *tfe.PolicyCheck) PolicyCheck {
+	result := PolicyCheck{
+		ID:         polchk.ID,
+		Kind:       string(tfe.Sentinel),
+		Scope:      string(polchk.Scope),
+		Status:     string(polchk.Status),
+		PolicySets: []PolicyCheckPolicySet{},
+	}
+
+	if polchk.Result == nil {
+		return result
+	}
 
-	result.ID = polchk.ID
-	result.Scope = polchk.Scope
-	result.Status = polchk.Status
 	result.Result.AdvisoryFailed = polchk.Result.AdvisoryFailed
 	result.Result.HardFailed = polchk.Result.HardFailed
 	result.Result.TotalFailed = polchk.Result.TotalFailed
@@ -131,5 +417,225 @@ func overridePolicyChecks(client *tfe.Client, policyCheckID string) (PolicyCheck
 	result.Result.Sentinel = polchk.Result.Sentinel
 	result.Result.Result = polchk.Result.Result
 
+	result.PolicySets = sentinelPolicySets(polchk.Result.Sentinel)
+
+	return result
+}
+
+func newPolicyEvaluationCheck(client *tfe.Client, evaluation *tfe.PolicyEvaluation) (PolicyCheck, error) {
+	result := PolicyCheck{
+		ID:         evaluation.ID,
+		Kind:       string(evaluation.PolicyKind),
+		Status:     string(evaluation.Status),
+		PolicySets: []PolicyCheckPolicySet{},
+	}
+
+	if evaluation.ResultCount != nil {
+		result.Result.AdvisoryFailed = evaluation.ResultCount.AdvisoryFailed
+		result.Result.HardFailed = evaluation.ResultCount.MandatoryFailed
+		result.Result.Passed = evaluation.ResultCount.Passed
+		result.Result.TotalFailed = evaluation.ResultCount.AdvisoryFailed + evaluation.ResultCount.MandatoryFailed
+	}
+	result.Result.Result = evaluation.Status == tfe.PolicyEvaluationPassed || evaluation.Status == tfe.PolicyEvaluationOverridden
+
+	log.Debugf("Retrieving policy set outcomes for policy evaluation: %s\n", evaluation.ID)
+	outcomes, err := client.PolicySetOutcomes.List(context.Background(), evaluation.ID, nil)
+	if err != nil {
+		return result, err
+	}
+
+	for _, outcome := range outcomes.Items {
+		policySet := PolicyCheckPolicySet{
+			Name:     outcome.PolicySetName,
+			Error:    outcome.Error,
+			Result:   outcome.Error == "" && outcome.ResultCount.MandatoryFailed == 0 && outcome.ResultCount.Errored == 0,
+			Policies: []PolicyCheckPolicy{},
+		}
+
+		for _, o := range outcome.Outcomes {
+			policySet.Policies = append(policySet.Policies, PolicyCheckPolicy{
+				Name:             o.PolicyName,
+				EnforcementLevel: string(o.EnforcementLevel),
+				Passed:           o.Status == "passed",
+				Description:      o.Description,
+			})
+		}
+
+		result.PolicySets = append(result.PolicySets, policySet)
+	}
+
 	return result, nil
 }
+
+// sentinelPolicySets breaks the Sentinel result of a policy check down per
+// policy set and per policy.
+func sentinelPolicySets(sentinel any) []PolicyCheckPolicySet {
+	results := []PolicyCheckPolicySet{}
+
+	data, err := json.Marshal(sentinel)
+	if err != nil {
+		return results
+	}
+
+	var sr sentinelResult
+	if err := json.Unmarshal(data, &sr); err != nil {
+		log.Warnf("unable to parse sentinel result: %v", err)
+		return results
+	}
+
+	var names []string
+	for name := range sr.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		set := sr.Data[name]
+		policySet := PolicyCheckPolicySet{
+			Name:     name,
+			Result:   set.Result,
+			Error:    sentinelError(set.Error),
+			Policies: []PolicyCheckPolicy{},
+		}
+
+		for _, p := range set.Policies {
+			policyName, enforcementLevel := sentinelPolicyName(p)
+
+			policy := PolicyCheckPolicy{
+				Name:             policyName,
+				EnforcementLevel: enforcementLevel,
+				Passed:           p.Result,
+				Error:            sentinelError(p.Error),
+			}
+
+			if p.Trace != nil {
+				policy.Description = p.Trace.Description
+				policy.Trace = formatSentinelTrace(p.Trace)
+			}
+
+			policySet.Policies = append(policySet.Policies, policy)
+		}
+
+		results = append(results, policySet)
+	}
+
+	return results
+}
+
+// sentinelPolicyName returns the name and enforcement level of the policy,
+// older results only contain the name of the policy so hard and soft
+// mandatory policies can't be told apart.
+func sentinelPolicyName(p sentinelPolicyResult) (string, string) {
+	enforcementLevel := "mandatory"
+	if p.AllowedFailure {
+		enforcementLevel = string(tfe.EnforcementAdvisory)
+	}
+
+	var name string
+	if err := json.Unmarshal(p.Policy, &name); err == nil {
+		return name, enforcementLevel
+	}
+
+	var policy struct {
+		Name             string `json:"name"`
+		EnforcementLevel string `json:"enforcement-level"`
+	}
+	if err := json.Unmarshal(p.Policy, &policy); err == nil {
+		if policy.EnforcementLevel != "" {
+			enforcementLevel = policy.EnforcementLevel
+		}
+		return policy.Name, enforcementLevel
+	}
+
+	return "", enforcementLevel
+}
+
+func sentinelError(err any) string {
+	if err == nil {
+		return ""
+	}
+	if s, ok := err.(string); ok {
+		return s
+	}
+
+	data, _ := json.Marshal(err)
+	return string(data)
+}
+
+// formatSentinelTrace renders the trace of a policy the way the Sentinel CLI
+// prints it.
+func formatSentinelTrace(trace *sentinelTrace) string {
+	var sb strings.Builder
+
+	if trace.Description != "" {
+		fmt.Fprintf(&sb, "Description:\n%s\n\n", indentLines(strings.TrimSpace(trace.Description), "  "))
+	}
+
+	if errorMessage := sentinelError(trace.Error); errorMessage != "" {
+		fmt.Fprintf(&sb, "Error:\n%s\n\n", indentLines(errorMessage, "  "))
+	}
+
+	if messages := strings.TrimSpace(trace.Print); messages != "" {
+		fmt.Fprintf(&sb, "Print messages:\n%s\n\n", indentLines(messages, "  "))
+	}
+
+	var idents []string
+	for ident := range trace.Rules {
+		idents = append(idents, ident)
+	}
+	sort.Strings(idents)
+
+	for _, ident := range idents {
+		rule := trace.Rules[ident]
+		value, _ := json.Marshal(rule.Value)
+		fmt.Fprintf(&sb, "Rule %q = %s\n", ident, value)
+		if rule.Desc != "" {
+			fmt.Fprintf(&sb, "  Description: %s\n", strings.TrimSpace(rule.Desc))
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+func renderPolicyTraces(policyChecks []PolicyCheck) string {
+	var sb strings.Builder
+
+	for _, pc := range policyChecks {
+		fmt.Fprintf(&sb, "%s (%s): %s\n", pc.ID, pc.Kind, pc.Status)
+
+		for _, ps := range pc.PolicySets {
+			fmt.Fprintf(&sb, "\n  Policy set: %s\n", ps.Name)
+			if ps.Error != "" {
+				fmt.Fprintf(&sb, "  Error: %s\n", ps.Error)
+			}
+
+			for _, p := range ps.Policies {
+				status := "PASS"
+				if !p.Passed {
+					status = "FAIL"
+				}
+
+				fmt.Fprintf(&sb, "\n    %s - %s (%s)\n", status, p.Name, p.EnforcementLevel)
+				if p.Trace != "" {
+					fmt.Fprintf(&sb, "%s\n", indentLines(p.Trace, "      "))
+				}
+			}
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// indentLines prefixes every non-empty line of s with prefix.
+func indentLines(s string, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
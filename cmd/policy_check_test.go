@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSentinelPolicySets(t *testing.T) {
+	tt := []struct {
+		name     string
+		sentinel string
+		want     []PolicyCheckPolicySet
+	}{
+		{
+			name:     "no data",
+			sentinel: `null`,
+			want:     []PolicyCheckPolicySet{},
+		},
+		{
+			name:     "unexpected shape",
+			sentinel: `{"data": []}`,
+			want:     []PolicyCheckPolicySet{},
+		},
+		{
+			name: "policy names as strings",
+			sentinel: `{"data": {
+				"set-b": {"error": null, "result": true, "policies": [
+					{"allowed-failure": true, "error": null, "policy": "set-b/regions", "result": false}
+				]},
+				"set-a": {"error": null, "result": true, "policies": [
+					{"allowed-failure": false, "error": null, "policy": "set-a/tags", "result": true}
+				]}
+			}}`,
+			want: []PolicyCheckPolicySet{
+				{Name: "set-a", Result: true, Policies: []PolicyCheckPolicy{
+					{Name: "set-a/tags", EnforcementLevel: "mandatory", Passed: true},
+				}},
+				{Name: "set-b", Result: true, Policies: []PolicyCheckPolicy{
+					{Name: "set-b/regions", EnforcementLevel: "advisory", Passed: false},
+				}},
+			},
+		},
+		{
+			name: "policy objects with enforcement level",
+			sentinel: `{"data": {
+				"set-a": {"error": null, "result": false, "policies": [
+					{"allowed-failure": false, "error": null, "policy": {"name": "set-a/tags", "enforcement-level": "hard-mandatory"}, "result": false}
+				]}
+			}}`,
+			want: []PolicyCheckPolicySet{
+				{Name: "set-a", Result: false, Policies: []PolicyCheckPolicy{
+					{Name: "set-a/tags", EnforcementLevel: "hard-mandatory", Passed: false},
+				}},
+			},
+		},
+		{
+			name: "errors and traces",
+			sentinel: `{"data": {
+				"set-a": {"error": {"message": "import failed"}, "result": false, "policies": [
+					{"allowed-failure": false, "error": "runtime error", "policy": "set-a/tags", "result": false,
+					 "trace": {"description": "Tags are mandatory", "print": "missing tag owner\n", "result": false,
+					           "rules": {"main": {"desc": "", "ident": "main", "value": false}}}}
+				]}
+			}}`,
+			want: []PolicyCheckPolicySet{
+				{Name: "set-a", Result: false, Error: `{"message":"import failed"}`, Policies: []PolicyCheckPolicy{
+					{
+						Name:             "set-a/tags",
+						EnforcementLevel: "mandatory",
+						Passed:           false,
+						Description:      "Tags are mandatory",
+						Error:            "runtime error",
+						Trace:            "Description:\n  Tags are mandatory\n\nPrint messages:\n  missing tag owner\n\nRule \"main\" = false",
+					},
+				}},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var sentinel any
+			require.NoError(t, json.Unmarshal([]byte(tc.sentinel), &sentinel))
+
+			require.Equal(t, tc.want, sentinelPolicySets(sentinel))
+		})
+	}
+}
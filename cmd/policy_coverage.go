@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Enforcement levels ordered from the least to the most strict
+var enforcementLevelOrder = map[string]int{
+	string(tfe.EnforcementAdvisory):  0,
+	string(tfe.EnforcementSoft):      1,
+	string(tfe.EnforcementMandatory): 2,
+	string(tfe.EnforcementHard):      3,
+}
+
+// Enforcement level of policies which can't be read, like the policies of VCS
+// backed policy sets
+const enforcementUnknown = "unknown"
+
+type CoveragePolicySet struct {
+	ID                string   `json:"id"`
+	Name              string   `json:"name"`
+	Kind              string   `json:"kind"`
+	Scope             string   `json:"scope"`
+	PolicyCount       int      `json:"policy_count"`
+	EnforcementLevels []string `json:"enforcement_levels"`
+}
+
+type WorkspaceCoverage struct {
+	WorkspaceID          string              `json:"workspace_id"`
+	WorkspaceName        string              `json:"workspace_name"`
+	ProjectID            string              `json:"project_id"`
+	Governed             bool                `json:"governed"`
+	StrictestEnforcement string              `json:"strictest_enforcement"`
+	PolicySets           []CoveragePolicySet `json:"policy_sets"`
+}
+
+var policyCoverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Report which policy sets govern each workspace",
+	Long: `Report which policy sets govern each workspace, resolving global policy sets,
+project and workspace attachments and workspace exclusions.
+Workspaces without any effective policy set containing policies are reported
+as not governed. The enforcement level of policies which can't be read, like
+the policies of VCS backed policy sets, is reported as unknown.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		filter, _ := cmd.Flags().GetString("filter")
+		ungoverned, _ := cmd.Flags().GetBool("ungoverned")
+
+		coverage, err := getPolicyCoverage(client, organization, filter)
+		check(err)
+
+		results := []WorkspaceCoverage{}
+		ungovernedCount := 0
+
+		for _, wc := range coverage {
+			if !wc.Governed {
+				ungovernedCount++
+			} else if ungoverned {
+				continue
+			}
+
+			results = append(results, wc)
+		}
+
+		if ungovernedCount > 0 {
+			log.Warnf("%d of %d workspaces are not governed by any policy set", ungovernedCount, len(coverage))
+		}
+
+		coverageJson, _ := json.MarshalIndent(results, "", "  ")
+		outputData(cmd, coverageJson)
+	},
+}
+
+func init() {
+	policyCmd.AddCommand(policyCoverageCmd)
+
+	policyCoverageCmd.Flags().String("filter", "", "Only report workspaces matching filter")
+	policyCoverageCmd.Flags().Bool("ungoverned", false, "Only report workspaces not governed by any policy set")
+}
+
+func getPolicyCoverage(client *tfe.Client, organization string, filter string) ([]WorkspaceCoverage, error) {
+	results := []WorkspaceCoverage{}
+
+	policies, err := listPolicies(client, organization, "")
+	if err != nil {
+		return nil, err
+	}
+
+	enforcementLevels := map[string]string{}
+	for _, p := range policies {
+		enforcementLevels[p.ID] = newPolicy(p).Enforce
+	}
+
+	policySets, err := listPolicySets(client, organization, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// The list of policy sets doesn't include all of their relations
+	var fullPolicySets []*tfe.PolicySet
+	for _, ps := range policySets {
+		full, err := readPolicySet(client, ps.ID)
+		if err != nil {
+			return nil, err
+		}
+		fullPolicySets = append(fullPolicySets, full)
+	}
+
+	workspaces, err := listWorkspaces(client, organization, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, workspace := range workspaces {
+		log.Debugf("Processing workspace: %s - %s", workspace.Name, workspace.ID)
+
+		projectID := ""
+		if workspace.Project != nil {
+			projectID = workspace.Project.ID
+		}
+
+		wc := workspaceCoverage(workspace.ID, workspace.Name, projectID, fullPolicySets, enforcementLevels)
+		results = append(results, wc)
+	}
+
+	return results, nil
+}
+
+// workspaceCoverage resolves the policy sets applying to the workspace. A
+// workspace is governed when one of them contains at least one policy.
+func workspaceCoverage(workspaceID string, workspaceName string, projectID string, policySets []*tfe.PolicySet, enforcementLevels map[string]string) WorkspaceCoverage {
+	wc := WorkspaceCoverage{
+		WorkspaceID:   workspaceID,
+		WorkspaceName: workspaceName,
+		ProjectID:     projectID,
+		PolicySets:    []CoveragePolicySet{},
+	}
+
+	for _, ps := range policySets {
+		scope := policySetScope(ps, workspaceID, projectID)
+		if scope == "" {
+			continue
+		}
+
+		cps := coveragePolicySet(ps, scope, enforcementLevels)
+		wc.PolicySets = append(wc.PolicySets, cps)
+
+		if cps.PolicyCount == 0 {
+			log.Debugf("Policy set %s has no policies, it doesn't govern workspace: %s", ps.Name, workspaceID)
+			continue
+		}
+		wc.Governed = true
+
+		level := cps.EnforcementLevels[0]
+		if wc.StrictestEnforcement == "" || enforcementLevelRank(level) > enforcementLevelRank(wc.StrictestEnforcement) {
+			wc.StrictestEnforcement = level
+		}
+	}
+
+	return wc
+}
+
+// coveragePolicySet lists the enforcement levels of the policies of the policy
+// set from the most to the least strict, adding unknown when some of its
+// policies can't be read.
+func coveragePolicySet(ps *tfe.PolicySet, scope string, enforcementLevels map[string]string) CoveragePolicySet {
+	result := CoveragePolicySet{
+		ID:                ps.ID,
+		Name:              ps.Name,
+		Kind:              string(ps.Kind),
+		Scope:             scope,
+		PolicyCount:       ps.PolicyCount,
+		EnforcementLevels: []string{},
+	}
+
+	// The policies of VCS backed policy sets aren't returned
+	if len(ps.Policies) > result.PolicyCount {
+		result.PolicyCount = len(ps.Policies)
+	}
+
+	known := 0
+	for _, p := range ps.Policies {
+		level, ok := enforcementLevels[p.ID]
+		if !ok {
+			continue
+		}
+		known++
+
+		if !containsString(result.EnforcementLevels, level) {
+			result.EnforcementLevels = append(result.EnforcementLevels, level)
+		}
+	}
+
+	if known < result.PolicyCount {
+		result.EnforcementLevels = append(result.EnforcementLevels, enforcementUnknown)
+	}
+
+	sort.Slice(result.EnforcementLevels, func(i, j int) bool {
+		return enforcementLevelRank(result.EnforcementLevels[i]) > enforcementLevelRank(result.EnforcementLevels[j])
+	})
+
+	return result
+}
+
+// enforcementLevelRank orders enforcement levels from the least to the most
+// strict, unknown being below all known levels
+func enforcementLevelRank(level string) int {
+	rank, ok := enforcementLevelOrder[level]
+	if !ok {
+		return -1
+	}
+
+	return rank
+}
+
+// policySetScope returns how the policy set applies to the workspace: global,
+// project or workspace, or an empty string when it doesn't apply.
+func policySetScope(ps *tfe.PolicySet, workspaceID string, projectID string) string {
+	for _, w := range ps.WorkspaceExclusions {
+		if w.ID == workspaceID {
+			return ""
+		}
+	}
+
+	for _, w := range ps.Workspaces {
+		if w.ID == workspaceID {
+			return "workspace"
+		}
+	}
+
+	if projectID != "" {
+		for _, p := range ps.Projects {
+			if p.ID == projectID {
+				return "project"
+			}
+		}
+	}
+
+	if ps.Global {
+		return "global"
+	}
+
+	return ""
+}
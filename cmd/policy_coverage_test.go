@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceCoverage(t *testing.T) {
+	enforcementLevels := map[string]string{
+		"pol-advisory":  string(tfe.EnforcementAdvisory),
+		"pol-mandatory": string(tfe.EnforcementHard),
+	}
+
+	tests := []struct {
+		name       string
+		policySets []*tfe.PolicySet
+		governed   bool
+		strictest  string
+		levels     [][]string
+	}{
+		{
+			name: "policies with known enforcement",
+			policySets: []*tfe.PolicySet{
+				{ID: "polset-1", Global: true, PolicyCount: 2, Policies: []*tfe.Policy{{ID: "pol-advisory"}, {ID: "pol-mandatory"}}},
+			},
+			governed:  true,
+			strictest: string(tfe.EnforcementHard),
+			levels:    [][]string{{string(tfe.EnforcementHard), string(tfe.EnforcementAdvisory)}},
+		},
+		{
+			name: "empty policy set",
+			policySets: []*tfe.PolicySet{
+				{ID: "polset-1", Global: true},
+			},
+			governed:  false,
+			strictest: "",
+			levels:    [][]string{{}},
+		},
+		{
+			name: "vcs policy set without policies relation",
+			policySets: []*tfe.PolicySet{
+				{ID: "polset-1", Global: true, PolicyCount: 3},
+			},
+			governed:  true,
+			strictest: enforcementUnknown,
+			levels:    [][]string{{enforcementUnknown}},
+		},
+		{
+			name: "known enforcement is stricter than unknown",
+			policySets: []*tfe.PolicySet{
+				{ID: "polset-1", Global: true, PolicyCount: 3},
+				{ID: "polset-2", Global: true, PolicyCount: 1, Policies: []*tfe.Policy{{ID: "pol-advisory"}}},
+				{ID: "polset-3", Global: true},
+			},
+			governed:  true,
+			strictest: string(tfe.EnforcementAdvisory),
+			levels:    [][]string{{enforcementUnknown}, {string(tfe.EnforcementAdvisory)}, {}},
+		},
+		{
+			name: "policy sets not applying to the workspace",
+			policySets: []*tfe.PolicySet{
+				{ID: "polset-1", Global: true, PolicyCount: 1, WorkspaceExclusions: []*tfe.Workspace{{ID: "ws-1"}}},
+				{ID: "polset-2", PolicyCount: 1, Projects: []*tfe.Project{{ID: "prj-2"}}},
+			},
+			governed:  false,
+			strictest: "",
+			levels:    [][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wc := workspaceCoverage("ws-1", "workspace-1", "prj-1", tt.policySets, enforcementLevels)
+
+			require.Equal(t, tt.governed, wc.Governed)
+			require.Equal(t, tt.strictest, wc.StrictestEnforcement)
+
+			levels := [][]string{}
+			for _, ps := range wc.PolicySets {
+				levels = append(levels, ps.EnforcementLevels)
+			}
+			require.Equal(t, tt.levels, levels)
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type PolicySetVersion struct {
+	ID           string `json:"id"`
+	PolicySetID  string `json:"policy_set_id"`
+	Source       string `json:"source"`
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+	CreatedAt    string `json:"created_at"`
+}
+
+var policySetCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a TFE policy set",
+	Long:  `Create a TFE policy set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		name, _ := cmd.Flags().GetString("name")
+		description, _ := cmd.Flags().GetString("description")
+		kind, _ := cmd.Flags().GetString("kind")
+		global, _ := cmd.Flags().GetBool("global")
+		overridable, _ := cmd.Flags().GetBool("overridable")
+		policies, _ := cmd.Flags().GetString("policies")
+		workspaces, _ := cmd.Flags().GetString("workspaces")
+		projects, _ := cmd.Flags().GetString("projects")
+
+		if name == "" {
+			log.Fatal("please provide the name of the policy set to perform this operation!")
+		}
+
+		options := tfe.PolicySetCreateOptions{
+			Name:        &name,
+			Description: &description,
+			Kind:        tfe.PolicyKind(kind),
+			Global:      &global,
+		}
+
+		if cmd.Flags().Changed("overridable") {
+			options.Overridable = &overridable
+		}
+
+		for _, id := range splitIDs(policies) {
+			options.Policies = append(options.Policies, &tfe.Policy{ID: id})
+		}
+		for _, id := range splitIDs(workspaces) {
+			options.Workspaces = append(options.Workspaces, &tfe.Workspace{ID: id})
+		}
+		for _, id := range splitIDs(projects) {
+			options.Projects = append(options.Projects, &tfe.Project{ID: id})
+		}
+
+		log.Debugf("Creating policy set: %s", name)
+		ps, err := client.PolicySets.Create(context.Background(), organization, options)
+		check(err)
+
+		outputPolicySet(cmd, client, ps.ID)
+	},
+}
+
+var policySetUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update a TFE policy set",
+	Long: `Update a TFE policy set.
+Only the given flags are updated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+		name, _ := cmd.Flags().GetString("name")
+		description, _ := cmd.Flags().GetString("description")
+		global, _ := cmd.Flags().GetBool("global")
+		overridable, _ := cmd.Flags().GetBool("overridable")
+
+		options := tfe.PolicySetUpdateOptions{}
+
+		if cmd.Flags().Changed("name") {
+			options.Name = &name
+		}
+		if cmd.Flags().Changed("description") {
+			options.Description = &description
+		}
+		if cmd.Flags().Changed("global") {
+			options.Global = &global
+		}
+		if cmd.Flags().Changed("overridable") {
+			options.Overridable = &overridable
+		}
+
+		log.Debugf("Updating policy set: %s", id)
+		_, err = client.PolicySets.Update(context.Background(), id, options)
+		check(err)
+
+		outputPolicySet(cmd, client, id)
+	},
+}
+
+var policySetDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a TFE policy set",
+	Long:  `Delete a TFE policy set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+
+		log.Debugf("Deleting policy set: %s", id)
+		err = client.PolicySets.Delete(context.Background(), id)
+		check(err)
+
+		log.Infof("Deleted policy set %s", id)
+	},
+}
+
+var policySetAttachCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Attach a TFE policy set to workspaces and projects",
+	Long:  `Attach a TFE policy set to workspaces and projects.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, workspaces, projects := getPolicySetAttachFlags(cmd)
+
+		err = attachPolicySet(client, id, workspaces, projects)
+		check(err)
+
+		outputPolicySet(cmd, client, id)
+	},
+}
+
+var policySetDetachCmd = &cobra.Command{
+	Use:   "detach",
+	Short: "Detach a TFE policy set from workspaces and projects",
+	Long:  `Detach a TFE policy set from workspaces and projects.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, workspaces, projects := getPolicySetAttachFlags(cmd)
+
+		err = detachPolicySet(client, id, workspaces, projects)
+		check(err)
+
+		outputPolicySet(cmd, client, id)
+	},
+}
+
+var policySetExcludeCmd = &cobra.Command{
+	Use:   "exclude",
+	Short: "Exclude workspaces from a TFE policy set",
+	Long: `Exclude workspaces from a TFE policy set, e.g. from a global policy set or a policy set attached to their project.
+With --remove, the workspaces are no longer excluded.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+		workspaces, _ := cmd.Flags().GetString("workspaces")
+		remove, _ := cmd.Flags().GetBool("remove")
+
+		var workspaceList []*tfe.Workspace
+		for _, wsID := range splitIDs(workspaces) {
+			workspaceList = append(workspaceList, &tfe.Workspace{ID: wsID})
+		}
+
+		if len(workspaceList) == 0 {
+			log.Fatal("please provide the workspaces to perform this operation!")
+		}
+
+		if remove {
+			log.Debugf("Removing workspace exclusions of policy set: %s", id)
+			err = client.PolicySets.RemoveWorkspaceExclusions(context.Background(), id, tfe.PolicySetRemoveWorkspaceExclusionsOptions{
+				WorkspaceExclusions: workspaceList,
+			})
+		} else {
+			log.Debugf("Adding workspace exclusions to policy set: %s", id)
+			err = client.PolicySets.AddWorkspaceExclusions(context.Background(), id, tfe.PolicySetAddWorkspaceExclusionsOptions{
+				WorkspaceExclusions: workspaceList,
+			})
+		}
+		check(err)
+
+		outputPolicySet(cmd, client, id)
+	},
+}
+
+var policySetUploadCmd = &cobra.Command{
+	Use:   "upload",
+	Short: "Upload a local directory of policies as a new version of a TFE policy set",
+	Long: `Upload a local directory of policies as a new version of a TFE policy set.
+The directory has to contain the sentinel.hcl or policies.hcl configuration of the policy set.
+Waits until the new version is ingested, exits with a non-zero status when the ingestion fails.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+		dir, _ := cmd.Flags().GetString("dir")
+		pollInterval, _ := cmd.Flags().GetDuration("poll-interval")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		if dir == "" {
+			log.Fatal("please provide the directory containing the policies to perform this operation!")
+		}
+
+		psv, err := uploadPolicySetVersion(client, id, dir, pollInterval, timeout)
+
+		policySetVersionJson, _ := json.MarshalIndent(psv, "", "  ")
+		outputData(cmd, policySetVersionJson)
+
+		check(err)
+	},
+}
+
+func init() {
+	// Create sub-command
+	policySetCmd.AddCommand(policySetCreateCmd)
+	policySetCreateCmd.Flags().String("name", "", "Name of the policy set")
+	policySetCreateCmd.Flags().String("description", "Policy Set Created by tfectl", "Description for the policy set")
+	policySetCreateCmd.Flags().String("kind", string(tfe.Sentinel), "Kind of policy set: sentinel or opa")
+	policySetCreateCmd.Flags().Bool("global", false, "Enforce the policy set on all workspaces of the organization")
+	policySetCreateCmd.Flags().Bool("overridable", false, "Allow failed policy evaluations to be overridden (OPA only)")
+	policySetCreateCmd.Flags().String("policies", "", "Comma separated list of policyIDs to add to the policy set")
+	policySetCreateCmd.Flags().String("workspaces", "", "Comma separated list of workspaceIDs to attach the policy set to")
+	policySetCreateCmd.Flags().String("projects", "", "Comma separated list of projectIDs to attach the policy set to")
+
+	// Update sub-command
+	policySetCmd.AddCommand(policySetUpdateCmd)
+	policySetUpdateCmd.Flags().String("id", "", "ID of the policy set")
+	policySetUpdateCmd.Flags().String("name", "", "Name of the policy set")
+	policySetUpdateCmd.Flags().String("description", "", "Description for the policy set")
+	policySetUpdateCmd.Flags().Bool("global", false, "Enforce the policy set on all workspaces of the organization")
+	policySetUpdateCmd.Flags().Bool("overridable", false, "Allow failed policy evaluations to be overridden (OPA only)")
+
+	// Delete sub-command
+	policySetCmd.AddCommand(policySetDeleteCmd)
+	policySetDeleteCmd.Flags().String("id", "", "ID of the policy set")
+
+	// Attach and detach sub-commands
+	for _, c := range []*cobra.Command{policySetAttachCmd, policySetDetachCmd} {
+		policySetCmd.AddCommand(c)
+		c.Flags().String("id", "", "ID of the policy set")
+		c.Flags().String("workspaces", "", "Comma separated list of workspaceIDs")
+		c.Flags().String("projects", "", "Comma separated list of projectIDs")
+	}
+
+	// Exclude sub-command
+	policySetCmd.AddCommand(policySetExcludeCmd)
+	policySetExcludeCmd.Flags().String("id", "", "ID of the policy set")
+	policySetExcludeCmd.Flags().String("workspaces", "", "Comma separated list of workspaceIDs to exclude")
+	policySetExcludeCmd.Flags().Bool("remove", false, "Remove the exclusion of the workspaces instead")
+
+	// Upload sub-command
+	policySetCmd.AddCommand(policySetUploadCmd)
+	policySetUploadCmd.Flags().String("id", "", "ID of the policy set")
+	policySetUploadCmd.Flags().String("dir", "", "Directory containing the policies and the policy set configuration")
+	policySetUploadCmd.Flags().Duration("poll-interval", 2*time.Second, "Interval between policy set version status checks")
+	policySetUploadCmd.Flags().Duration("timeout", 5*time.Minute, "Maximum time to wait for the policy set version to be ingested")
+}
+
+func getPolicySetAttachFlags(cmd *cobra.Command) (string, []string, []string) {
+	id, _ := cmd.Flags().GetString("id")
+	workspaces, _ := cmd.Flags().GetString("workspaces")
+	projects, _ := cmd.Flags().GetString("projects")
+
+	if workspaces == "" && projects == "" {
+		log.Fatal("please provide workspaces and/or projects to perform this operation!")
+	}
+
+	return id, splitIDs(workspaces), splitIDs(projects)
+}
+
+func attachPolicySet(client *tfe.Client, policySetID string, workspaceIDs []string, projectIDs []string) error {
+	if len(workspaceIDs) > 0 {
+		options := tfe.PolicySetAddWorkspacesOptions{}
+		for _, id := range workspaceIDs {
+			options.Workspaces = append(options.Workspaces, &tfe.Workspace{ID: id})
+		}
+
+		log.Debugf("Attaching policy set %s to workspaces: %v", policySetID, workspaceIDs)
+		if err := client.PolicySets.AddWorkspaces(context.Background(), policySetID, options); err != nil {
+			return err
+		}
+	}
+
+	if len(projectIDs) > 0 {
+		options := tfe.PolicySetAddProjectsOptions{}
+		for _, id := range projectIDs {
+			options.Projects = append(options.Projects, &tfe.Project{ID: id})
+		}
+
+		log.Debugf("Attaching policy set %s to projects: %v", policySetID, projectIDs)
+		if err := client.PolicySets.AddProjects(context.Background(), policySetID, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func detachPolicySet(client *tfe.Client, policySetID string, workspaceIDs []string, projectIDs []string) error {
+	if len(workspaceIDs) > 0 {
+		options := tfe.PolicySetRemoveWorkspacesOptions{}
+		for _, id := range workspaceIDs {
+			options.Workspaces = append(options.Workspaces, &tfe.Workspace{ID: id})
+		}
+
+		log.Debugf("Detaching policy set %s from workspaces: %v", policySetID, workspaceIDs)
+		if err := client.PolicySets.RemoveWorkspaces(context.Background(), policySetID, options); err != nil {
+			return err
+		}
+	}
+
+	if len(projectIDs) > 0 {
+		options := tfe.PolicySetRemoveProjectsOptions{}
+		for _, id := range projectIDs {
+			options.Projects = append(options.Projects, &tfe.Project{ID: id})
+		}
+
+		log.Debugf("Detaching policy set %s from projects: %v", policySetID, projectIDs)
+		if err := client.PolicySets.RemoveProjects(context.Background(), policySetID, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// outputPolicySet reads the policy set with its relations and outputs it as a
+// single element list, in the same format as policy-set list.
+func outputPolicySet(cmd *cobra.Command, client *tfe.Client, policySetID string) {
+	ps, err := readPolicySet(client, policySetID)
+	check(err)
+
+	policySetJson, _ := json.MarshalIndent([]PolicySet{newPolicySet(ps)}, "", "  ")
+	outputData(cmd, policySetJson)
+}
+
+// readPolicySet reads the policy set including its policies, workspaces,
+// projects and workspace exclusions.
+func readPolicySet(client *tfe.Client, policySetID string) (*tfe.PolicySet, error) {
+	log.Debugf("Reading policy set: %s", policySetID)
+	return client.PolicySets.ReadWithOptions(context.Background(), policySetID, &tfe.PolicySetReadOptions{
+		Include: []tfe.PolicySetIncludeOpt{
+			tfe.PolicySetPolicies,
+			tfe.PolicySetWorkspaces,
+			tfe.PolicySetProjects,
+			tfe.PolicySetWorkspaceExclusions,
+		},
+	})
+}
+
+func newPolicySet(ps *tfe.PolicySet) PolicySet {
+	result := PolicySet{
+		ID:             ps.ID,
+		Name:           ps.Name,
+		Kind:           string(ps.Kind),
+		Global:         ps.Global,
+		WorkspaceCount: ps.WorkspaceCount,
+		ProjectCount:   ps.ProjectCount,
+		PolicyCount:    ps.PolicyCount,
+	}
+
+	for _, w := range ps.Workspaces {
+		result.Workspaces = append(result.Workspaces, w.ID)
+	}
+	for _, w := range ps.WorkspaceExclusions {
+		result.WorkspaceExclusions = append(result.WorkspaceExclusions, w.ID)
+	}
+	for _, p := range ps.Projects {
+		result.Projects = append(result.Projects, p.ID)
+	}
+	for _, p := range ps.Policies {
+		result.Policies = append(result.Policies, p.ID)
+	}
+
+	return result
+}
+
+// uploadPolicySetVersion creates a new version of the policy set from the
+// directory and waits until it is ingested.
+func uploadPolicySetVersion(client *tfe.Client, policySetID string, dir string, pollInterval time.Duration, timeout time.Duration) (PolicySetVersion, error) {
+	result := PolicySetVersion{PolicySetID: policySetID}
+
+	log.Debugf("Creating policy set version of policy set: %s", policySetID)
+	psv, err := client.PolicySetVersions.Create(context.Background(), policySetID)
+	if err != nil {
+		return result, err
+	}
+
+	log.Debugf("Uploading %s to policy set version: %s", dir, psv.ID)
+	err = client.PolicySetVersions.Upload(context.Background(), *psv, dir)
+	if err != nil {
+		return result, err
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		result.ID = psv.ID
+		result.Source = string(psv.Source)
+		result.Status = string(psv.Status)
+		result.ErrorMessage = psv.ErrorMessage
+		result.CreatedAt = psv.CreatedAt.Format(time.RFC3339)
+
+		log.Debugf("Policy set version %s is %s", psv.ID, psv.Status)
+
+		switch psv.Status {
+		case tfe.PolicySetVersionReady:
+			return result, nil
+		case tfe.PolicySetVersionErrored:
+			return result, fmt.Errorf("policy set version %s errored: %s", psv.ID, psv.ErrorMessage)
+		}
+
+		if time.Now().After(deadline) {
+			return result, fmt.Errorf("policy set version %s not ingested after %s", psv.ID, timeout)
+		}
+
+		time.Sleep(pollInterval)
+
+		psv, err = client.PolicySetVersions.Read(context.Background(), psv.ID)
+		if err != nil {
+			return result, err
+		}
+	}
+}
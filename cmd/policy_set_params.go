@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type PolicySetParameter struct {
+	ID        string `json:"id"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+type PolicySetParameters struct {
+	Parameters []PolicySetParameter `json:"parameters"`
+}
+
+var policySetParamsCmd = &cobra.Command{
+	Use:   "params",
+	Short: "Manage parameters of TFE policy sets",
+	Long:  `Manage parameters of TFE policy sets.`,
+}
+
+var policySetParamsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List parameters of a TFE policy set",
+	Long: `List parameters of a TFE policy set.
+The values of sensitive parameters are never returned.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		policySetID, _ := cmd.Flags().GetString("policy-set-id")
+
+		parameters, err := listPolicySetParameters(client, policySetID)
+		check(err)
+
+		parametersJson, _ := json.MarshalIndent(parameters, "", "  ")
+		outputData(cmd, parametersJson)
+	},
+}
+
+var policySetParamsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a parameter of a TFE policy set",
+	Long:  `Create a parameter of a TFE policy set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		policySetID, _ := cmd.Flags().GetString("policy-set-id")
+		key, _ := cmd.Flags().GetString("key")
+		value, _ := cmd.Flags().GetString("value")
+		sensitive, _ := cmd.Flags().GetBool("sensitive")
+
+		err = resolveVariableSource(&value, &sensitive)
+		check(err)
+
+		p, err := createPolicySetParameter(client, policySetID, key, value, sensitive)
+		check(err)
+
+		parameterJson, _ := json.MarshalIndent(p, "", "  ")
+		outputData(cmd, parameterJson)
+	},
+}
+
+var policySetParamsCreateFromFileCmd = &cobra.Command{
+	Use:   "from-file",
+	Short: "Create policy set parameters using JSON file",
+	Long:  `Create policy set parameters using JSON file`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		file, _ := cmd.Flags().GetString("file")
+		policySetID, _ := cmd.Flags().GetString("policy-set-id")
+
+		byteParamJson := readJsonFile(file)
+
+		var parameters PolicySetParameters
+		var outputParametersList []PolicySetParameter
+
+		err = json.Unmarshal(byteParamJson, &parameters)
+		check(err)
+
+		for _, newParam := range parameters.Parameters {
+			err = resolveVariableSource(&newParam.Value, &newParam.Sensitive)
+			check(err)
+
+			p, err := createPolicySetParameter(client, policySetID, newParam.Key, newParam.Value, newParam.Sensitive)
+			check(err)
+			outputParametersList = append(outputParametersList, p)
+		}
+
+		outputParametersListJson, _ := json.MarshalIndent(outputParametersList, "", "  ")
+		outputData(cmd, outputParametersListJson)
+	},
+}
+
+var policySetParamsUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update a parameter of a TFE policy set",
+	Long: `Update a parameter of a TFE policy set.
+Only the given flags are updated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		policySetID, _ := cmd.Flags().GetString("policy-set-id")
+		parameterID, _ := cmd.Flags().GetString("parameter-id")
+		key, _ := cmd.Flags().GetString("key")
+		value, _ := cmd.Flags().GetString("value")
+		sensitive, _ := cmd.Flags().GetBool("sensitive")
+
+		options := tfe.PolicySetParameterUpdateOptions{}
+
+		if cmd.Flags().Changed("key") {
+			options.Key = &key
+		}
+		if cmd.Flags().Changed("value") {
+			// A value read from a secret source is always sensitive
+			sourced := false
+			err = resolveVariableSource(&value, &sourced)
+			check(err)
+
+			options.Value = &value
+			if sourced {
+				sensitive = true
+				options.Sensitive = &sensitive
+			}
+		}
+		if cmd.Flags().Changed("sensitive") && options.Sensitive == nil {
+			options.Sensitive = &sensitive
+		}
+
+		p, err := updatePolicySetParameter(client, policySetID, parameterID, options)
+		check(err)
+
+		parameterJson, _ := json.MarshalIndent(p, "", "  ")
+		outputData(cmd, parameterJson)
+	},
+}
+
+var policySetParamsUpdateFromFileCmd = &cobra.Command{
+	Use:   "from-file",
+	Short: "Update policy set parameters using JSON file",
+	Long:  `Update policy set parameters using JSON file`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		file, _ := cmd.Flags().GetString("file")
+		policySetID, _ := cmd.Flags().GetString("policy-set-id")
+
+		byteParamJson := readJsonFile(file)
+
+		var parameters PolicySetParameters
+		var outputParametersList []PolicySetParameter
+
+		err = json.Unmarshal(byteParamJson, &parameters)
+		check(err)
+
+		for _, newParam := range parameters.Parameters {
+			err = resolveVariableSource(&newParam.Value, &newParam.Sensitive)
+			check(err)
+
+			options := tfe.PolicySetParameterUpdateOptions{
+				Key:       &newParam.Key,
+				Value:     &newParam.Value,
+				Sensitive: &newParam.Sensitive,
+			}
+
+			p, err := updatePolicySetParameter(client, policySetID, newParam.ID, options)
+			check(err)
+			outputParametersList = append(outputParametersList, p)
+		}
+
+		outputParametersListJson, _ := json.MarshalIndent(outputParametersList, "", "  ")
+		outputData(cmd, outputParametersListJson)
+	},
+}
+
+var policySetParamsDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a parameter of a TFE policy set",
+	Long:  `Delete a parameter of a TFE policy set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		policySetID, _ := cmd.Flags().GetString("policy-set-id")
+		parameterID, _ := cmd.Flags().GetString("parameter-id")
+
+		log.Debugf("Deleting parameter %s of policy set: %s", parameterID, policySetID)
+		err = client.PolicySetParameters.Delete(context.Background(), policySetID, parameterID)
+		check(err)
+
+		parameters, err := listPolicySetParameters(client, policySetID)
+		check(err)
+
+		parametersJson, _ := json.MarshalIndent(parameters, "", "  ")
+		outputData(cmd, parametersJson)
+	},
+}
+
+func init() {
+	policySetCmd.AddCommand(policySetParamsCmd)
+
+	// List sub-command
+	policySetParamsCmd.AddCommand(policySetParamsListCmd)
+	policySetParamsListCmd.Flags().String("policy-set-id", "", "ID of the policy set")
+
+	// Create sub-command
+	policySetParamsCmd.AddCommand(policySetParamsCreateCmd)
+	policySetParamsCreateCmd.Flags().String("policy-set-id", "", "ID of the policy set")
+	policySetParamsCreateCmd.Flags().String("key", "", "Parameter Name")
+	policySetParamsCreateCmd.Flags().String("value", "", "Parameter Value")
+	policySetParamsCreateCmd.Flags().Bool("sensitive", false, "Set sensitive flag for parameter")
+	// Create from file sub-command
+	policySetParamsCreateCmd.AddCommand(policySetParamsCreateFromFileCmd)
+	policySetParamsCreateFromFileCmd.Flags().String("file", "", "File containing policy set parameters")
+	policySetParamsCreateFromFileCmd.Flags().String("policy-set-id", "", "ID of the policy set")
+
+	// Update sub-command
+	policySetParamsCmd.AddCommand(policySetParamsUpdateCmd)
+	policySetParamsUpdateCmd.Flags().String("policy-set-id", "", "ID of the policy set")
+	policySetParamsUpdateCmd.Flags().String("parameter-id", "", "ID of the parameter")
+	policySetParamsUpdateCmd.Flags().String("key", "", "Parameter Name")
+	policySetParamsUpdateCmd.Flags().String("value", "", "Parameter Value")
+	policySetParamsUpdateCmd.Flags().Bool("sensitive", false, "Set sensitive flag for parameter")
+	// Update from file sub-command
+	policySetParamsUpdateCmd.AddCommand(policySetParamsUpdateFromFileCmd)
+	policySetParamsUpdateFromFileCmd.Flags().String("file", "", "File containing policy set parameters")
+	policySetParamsUpdateFromFileCmd.Flags().String("policy-set-id", "", "ID of the policy set")
+
+	// Delete sub-command
+	policySetParamsCmd.AddCommand(policySetParamsDeleteCmd)
+	policySetParamsDeleteCmd.Flags().String("policy-set-id", "", "ID of the policy set")
+	policySetParamsDeleteCmd.Flags().String("parameter-id", "", "ID of the parameter")
+}
+
+func listPolicySetParameters(client *tfe.Client, policySetID string) ([]PolicySetParameter, error) {
+	results := []PolicySetParameter{}
+	currentPage := 1
+
+	for {
+		log.Debugf("Processing page %d.\n", currentPage)
+		options := &tfe.PolicySetParameterListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: currentPage,
+				PageSize:   50,
+			},
+		}
+
+		p, err := client.PolicySetParameters.List(context.Background(), policySetID, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, parameter := range p.Items {
+			results = append(results, newPolicySetParameter(parameter))
+		}
+
+		if p.NextPage == 0 {
+			break
+		}
+
+		currentPage++
+	}
+
+	return results, nil
+}
+
+func createPolicySetParameter(client *tfe.Client, policySetID string, key string, value string, sensitive bool) (PolicySetParameter, error) {
+	category := tfe.CategoryPolicySet
+	options := tfe.PolicySetParameterCreateOptions{
+		Key:       &key,
+		Value:     &value,
+		Category:  &category,
+		Sensitive: &sensitive,
+	}
+
+	log.Debugf("Creating parameter %s of policy set: %s", key, policySetID)
+	p, err := client.PolicySetParameters.Create(context.Background(), policySetID, options)
+	if err != nil {
+		return PolicySetParameter{}, err
+	}
+
+	return newPolicySetParameter(p), nil
+}
+
+func updatePolicySetParameter(client *tfe.Client, policySetID string, parameterID string, options tfe.PolicySetParameterUpdateOptions) (PolicySetParameter, error) {
+	log.Debugf("Updating parameter %s of policy set: %s", parameterID, policySetID)
+	p, err := client.PolicySetParameters.Update(context.Background(), policySetID, parameterID, options)
+	if err != nil {
+		return PolicySetParameter{}, err
+	}
+
+	return newPolicySetParameter(p), nil
+}
+
+func newPolicySetParameter(p *tfe.PolicySetParameter) PolicySetParameter {
+	return PolicySetParameter{
+		ID:        p.ID,
+		Key:       p.Key,
+		Value:     p.Value,
+		Sensitive: p.Sensitive,
+	}
+}
@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var registryModuleTestVarsCmd = &cobra.Command{
+	Use:   "test-vars",
+	Short: "Manage test variables of private registry modules",
+	Long: `Manage the variables used by terraform test runs of private registry modules.
+Modules are given as name/provider.`,
+}
+
+var registryModuleTestVarsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List test variables of a private registry module",
+	Long:  `List test variables of a private registry module.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		moduleID := getTestVarsModuleID(cmd, organization)
+
+		variables, err := listTestVariables(client, moduleID)
+		check(err)
+
+		variablesJson, _ := json.MarshalIndent(variables, "", "  ")
+		outputData(cmd, variablesJson)
+	},
+}
+
+var registryModuleTestVarsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a test variable of a private registry module",
+	Long:  `Create a test variable of a private registry module.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		moduleID := getTestVarsModuleID(cmd, organization)
+		key, _ := cmd.Flags().GetString("key")
+		value, _ := cmd.Flags().GetString("value")
+		description, _ := cmd.Flags().GetString("description")
+		categoryTypeStr, _ := cmd.Flags().GetString("type")
+		hcl, _ := cmd.Flags().GetBool("hcl")
+		sensitive, _ := cmd.Flags().GetBool("sensitive")
+
+		categoryType := tfe.CategoryType(categoryTypeStr)
+
+		err = resolveVariableSource(&value, &sensitive)
+		check(err)
+
+		v, err := createTestVariable(client, moduleID, &key, &value, &description, &categoryType, &hcl, &sensitive)
+		check(err)
+
+		variableJson, _ := json.MarshalIndent(v, "", "  ")
+		outputData(cmd, variableJson)
+	},
+}
+
+var registryModuleTestVarsCreateFromFileCmd = &cobra.Command{
+	Use:   "from-file",
+	Short: "Create test variables using JSON file",
+	Long:  `Create test variables using JSON file`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		file, _ := cmd.Flags().GetString("file")
+		moduleID := getTestVarsModuleID(cmd, organization)
+
+		byteVarJson := readJsonFile(file)
+
+		var variables Variables
+		var outputVariablesList []Variable
+
+		err = json.Unmarshal(byteVarJson, &variables)
+		check(err)
+
+		for _, newVar := range variables.Variables {
+			err = resolveVariableSource(&newVar.Value, &newVar.Sensitive)
+			check(err)
+
+			v, err := createTestVariable(client, moduleID, &newVar.Key, &newVar.Value, &newVar.Description, &newVar.Category, &newVar.HCL, &newVar.Sensitive)
+			check(err)
+			outputVariablesList = append(outputVariablesList, v)
+		}
+
+		outputVariablesListJson, _ := json.MarshalIndent(outputVariablesList, "", "  ")
+		outputData(cmd, outputVariablesListJson)
+	},
+}
+
+var registryModuleTestVarsUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update a test variable of a private registry module",
+	Long: `Update a test variable of a private registry module.
+Only the attributes given with flags are updated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		moduleID := getTestVarsModuleID(cmd, organization)
+		variableID, _ := cmd.Flags().GetString("variable-id")
+		key, _ := cmd.Flags().GetString("key")
+		value, _ := cmd.Flags().GetString("value")
+		description, _ := cmd.Flags().GetString("description")
+		categoryTypeStr, _ := cmd.Flags().GetString("type")
+		hcl, _ := cmd.Flags().GetBool("hcl")
+		sensitive, _ := cmd.Flags().GetBool("sensitive")
+
+		// Only the given flags are updated
+		var keyOpt, valueOpt, descriptionOpt *string
+		var hclOpt, sensitiveOpt *bool
+		var categoryType *tfe.CategoryType
+
+		if cmd.Flags().Changed("key") {
+			keyOpt = &key
+		}
+		if cmd.Flags().Changed("value") {
+			// Values read from a secret source are always sensitive
+			sourced := false
+			err = resolveVariableSource(&value, &sourced)
+			check(err)
+
+			valueOpt = &value
+			if sourced {
+				sensitive = true
+				sensitiveOpt = &sensitive
+			}
+		}
+		if cmd.Flags().Changed("description") {
+			descriptionOpt = &description
+		}
+		if cmd.Flags().Changed("type") {
+			categoryType = (*tfe.CategoryType)(&categoryTypeStr)
+		}
+		if cmd.Flags().Changed("hcl") {
+			hclOpt = &hcl
+		}
+		if cmd.Flags().Changed("sensitive") && sensitiveOpt == nil {
+			sensitiveOpt = &sensitive
+		}
+
+		v, err := updateTestVariable(client, moduleID, variableID, keyOpt, valueOpt, descriptionOpt, categoryType, hclOpt, sensitiveOpt)
+		check(err)
+
+		variableJson, _ := json.MarshalIndent(v, "", "  ")
+		outputData(cmd, variableJson)
+	},
+}
+
+var registryModuleTestVarsUpdateFromFileCmd = &cobra.Command{
+	Use:   "from-file",
+	Short: "Update test variables using JSON file",
+	Long:  `Update test variables using JSON file`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		file, _ := cmd.Flags().GetString("file")
+		moduleID := getTestVarsModuleID(cmd, organization)
+
+		byteVarJson := readJsonFile(file)
+
+		var variables Variables
+		var outputVariablesList []Variable
+
+		err = json.Unmarshal(byteVarJson, &variables)
+		check(err)
+
+		for _, newVar := range variables.Variables {
+			err = resolveVariableSource(&newVar.Value, &newVar.Sensitive)
+			check(err)
+
+			var categoryType *tfe.CategoryType
+			if newVar.Category != "" {
+				categoryType = &newVar.Category
+			}
+
+			v, err := updateTestVariable(client, moduleID, newVar.ID, &newVar.Key, &newVar.Value, &newVar.Description, categoryType, &newVar.HCL, &newVar.Sensitive)
+			check(err)
+			outputVariablesList = append(outputVariablesList, v)
+		}
+
+		outputVariablesListJson, _ := json.MarshalIndent(outputVariablesList, "", "  ")
+		outputData(cmd, outputVariablesListJson)
+	},
+}
+
+var registryModuleTestVarsDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a test variable of a private registry module",
+	Long:  `Delete a test variable of a private registry module.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		moduleID := getTestVarsModuleID(cmd, organization)
+		variableID, _ := cmd.Flags().GetString("variable-id")
+
+		log.Debugf("Deleting test variable %s of module: %s/%s", variableID, moduleID.Name, moduleID.Provider)
+		err = client.TestVariables.Delete(context.Background(), moduleID, variableID)
+		check(err)
+
+		variables, err := listTestVariables(client, moduleID)
+		check(err)
+
+		variablesJson, _ := json.MarshalIndent(variables, "", "  ")
+		outputData(cmd, variablesJson)
+	},
+}
+
+func init() {
+	registryModuleCmd.AddCommand(registryModuleTestVarsCmd)
+
+	// List sub-command
+	registryModuleTestVarsCmd.AddCommand(registryModuleTestVarsListCmd)
+	registryModuleTestVarsListCmd.Flags().String("module", "", "Module as name/provider")
+
+	// Create sub-command
+	registryModuleTestVarsCmd.AddCommand(registryModuleTestVarsCreateCmd)
+	registryModuleTestVarsCreateCmd.Flags().String("module", "", "Module as name/provider")
+	registryModuleTestVarsCreateCmd.Flags().String("key", "", "Variable Name")
+	registryModuleTestVarsCreateCmd.Flags().String("value", "", "Variable Value")
+	registryModuleTestVarsCreateCmd.Flags().Bool("sensitive", false, "Set sensitive flag for variable")
+	registryModuleTestVarsCreateCmd.Flags().Bool("hcl", false, "Set if variable has HCL syntax")
+	registryModuleTestVarsCreateCmd.Flags().String("type", "env", "Variable type")
+	registryModuleTestVarsCreateCmd.Flags().String("description", "Variable Created by tfectl", "Description for the variable")
+	// Create from file sub-command
+	registryModuleTestVarsCreateCmd.AddCommand(registryModuleTestVarsCreateFromFileCmd)
+	registryModuleTestVarsCreateFromFileCmd.Flags().String("file", "", "File containing test variables")
+	registryModuleTestVarsCreateFromFileCmd.Flags().String("module", "", "Module as name/provider")
+
+	// Update sub-command
+	registryModuleTestVarsCmd.AddCommand(registryModuleTestVarsUpdateCmd)
+	registryModuleTestVarsUpdateCmd.Flags().String("module", "", "Module as name/provider")
+	registryModuleTestVarsUpdateCmd.Flags().String("variable-id", "", "variableID")
+	registryModuleTestVarsUpdateCmd.Flags().String("key", "", "Variable Name, unchanged unless given")
+	registryModuleTestVarsUpdateCmd.Flags().String("value", "", "Variable Value, unchanged unless given")
+	registryModuleTestVarsUpdateCmd.Flags().Bool("sensitive", false, "Set sensitive flag for variable, unchanged unless given")
+	registryModuleTestVarsUpdateCmd.Flags().Bool("hcl", false, "Set if variable has HCL syntax, unchanged unless given")
+	registryModuleTestVarsUpdateCmd.Flags().String("type", "", "Variable type, unchanged unless given")
+	registryModuleTestVarsUpdateCmd.Flags().String("description", "", "Description for the variable, unchanged unless given")
+	// Update from file sub-command
+	registryModuleTestVarsUpdateCmd.AddCommand(registryModuleTestVarsUpdateFromFileCmd)
+	registryModuleTestVarsUpdateFromFileCmd.Flags().String("file", "", "File containing test variables")
+	registryModuleTestVarsUpdateFromFileCmd.Flags().String("module", "", "Module as name/provider")
+
+	// Delete sub-command
+	registryModuleTestVarsCmd.AddCommand(registryModuleTestVarsDeleteCmd)
+	registryModuleTestVarsDeleteCmd.Flags().String("module", "", "Module as name/provider")
+	registryModuleTestVarsDeleteCmd.Flags().String("variable-id", "", "variableID of the variable")
+}
+
+// getTestVarsModuleID parses the module flag, given as name/provider, into
+// the ID of a private registry module of the organization
+func getTestVarsModuleID(cmd *cobra.Command, organization string) tfe.RegistryModuleID {
+	module, _ := cmd.Flags().GetString("module")
+
+	moduleID, err := parseTestVarsModuleID(module, organization)
+	check(err)
+
+	return moduleID
+}
+
+func parseTestVarsModuleID(module string, organization string) (tfe.RegistryModuleID, error) {
+	name, provider, ok := strings.Cut(module, "/")
+	if !ok || name == "" || provider == "" || strings.Contains(provider, "/") {
+		return tfe.RegistryModuleID{}, fmt.Errorf("module %q has to be given as name/provider", module)
+	}
+
+	return tfe.RegistryModuleID{
+		Organization: organization,
+		Name:         name,
+		Provider:     provider,
+		Namespace:    organization,
+		RegistryName: tfe.PrivateRegistry,
+	}, nil
+}
+
+func listTestVariables(client *tfe.Client, moduleID tfe.RegistryModuleID) ([]Variable, error) {
+	results := []Variable{}
+	currentPage := 1
+
+	for {
+		log.Debugf("Processing page %d.\n", currentPage)
+		options := &tfe.VariableListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: currentPage,
+				PageSize:   50,
+			},
+		}
+
+		varList, err := client.TestVariables.List(context.Background(), moduleID, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range varList.Items {
+			results = append(results, newTestVariable(v))
+		}
+
+		// The test variables endpoint may not paginate
+		if varList.Pagination == nil || varList.NextPage == 0 {
+			break
+		}
+
+		currentPage++
+	}
+
+	return results, nil
+}
+
+func createTestVariable(client *tfe.Client, moduleID tfe.RegistryModuleID, key *string, value *string, description *string, category *tfe.CategoryType, hcl *bool, sensitive *bool) (Variable, error) {
+	options := tfe.VariableCreateOptions{
+		Key:         key,
+		Value:       value,
+		Description: description,
+		Category:    category,
+		HCL:         hcl,
+		Sensitive:   sensitive,
+	}
+
+	log.Debugf("Creating test variable %s of module: %s/%s", *key, moduleID.Name, moduleID.Provider)
+	v, err := client.TestVariables.Create(context.Background(), moduleID, options)
+	if err != nil {
+		return Variable{}, err
+	}
+
+	return newTestVariable(v), nil
+}
+
+func updateTestVariable(client *tfe.Client, moduleID tfe.RegistryModuleID, variableID string, key *string, value *string, description *string, category *tfe.CategoryType, hcl *bool, sensitive *bool) (Variable, error) {
+	options := tfe.VariableUpdateOptions{
+		Key:         key,
+		Value:       value,
+		Description: description,
+		Category:    category,
+		HCL:         hcl,
+		Sensitive:   sensitive,
+	}
+
+	log.Debugf("Updating test variable %s of module: %s/%s", variableID, moduleID.Name, moduleID.Provider)
+	v, err := client.TestVariables.Update(context.Background(), moduleID, variableID, options)
+	if err != nil {
+		return Variable{}, err
+	}
+
+	return newTestVariable(v), nil
+}
+
+func newTestVariable(v *tfe.Variable) Variable {
+	return Variable{
+		ID:          v.ID,
+		Key:         v.Key,
+		Value:       v.Value,
+		Description: v.Description,
+		Category:    v.Category,
+		HCL:         v.HCL,
+		Sensitive:   v.Sensitive,
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTestVarsModuleID(t *testing.T) {
+	tt := []struct {
+		module string
+		want   tfe.RegistryModuleID
+		err    bool
+	}{
+		{
+			module: "windows-instance/azurerm",
+			want: tfe.RegistryModuleID{
+				Organization: "org",
+				Name:         "windows-instance",
+				Provider:     "azurerm",
+				Namespace:    "org",
+				RegistryName: tfe.PrivateRegistry,
+			},
+		},
+		{module: "windows-instance/", err: true},
+		{module: "/azurerm", err: true},
+		{module: "windows-instance", err: true},
+		{module: "org/windows-instance/azurerm", err: true},
+		{module: "", err: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.module, func(t *testing.T) {
+			got, err := parseTestVarsModuleID(tc.module, "org")
+
+			if tc.err {
+				require.EqualError(t, err, `module "`+tc.module+`" has to be given as name/provider`)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -17,6 +17,7 @@ Usage:
 Available Commands:
   admin             Manage TFE admin operations
   agent-pool        Query TFE/TFC Agent Pools
+  apply             Query TFE Applies
   completion        Generate the autocompletion script for the specified shell
   help              Help about any command
   plan              Query TFE Plans
@@ -29,6 +30,7 @@ Available Commands:
   tag               Query TFE tags
   team              Manage TFE teams
   variable          Manage TFE workspace variables
+  varset            Manage TFE variable sets
   workspace         Manage TFE workspaces
 
 Flags:
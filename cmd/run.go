@@ -183,6 +183,7 @@ var runApplyCmd = &cobra.Command{
 		check(err)
 
 		ids, _ := cmd.Flags().GetString("ids")
+		comment, _ := cmd.Flags().GetString("comment")
 
 		var runApplyListJson []byte
 		var runApplyList []Run
@@ -192,14 +193,15 @@ var runApplyCmd = &cobra.Command{
 			var tmpRun Run
 
 			// get workspaceID from run
-			run, _ := getRun(client, id)
+			run, err := getRun(client, id)
+			check(err)
 			workspaceID := run.Workspace.ID
 
 			// get workspaceName from run
 			workspaceName, _ := getWorkspaceNameByID(client, organization, workspaceID)
 
 			log.Debugf("Applying run with id: %s", id)
-			applyRun(client, id)
+			applyRun(client, id, comment)
 
 			entry := fmt.Sprintf(`{
         "id":"%s",
@@ -247,7 +249,8 @@ var runGetCmd = &cobra.Command{
 			var tmpRun Run
 
 			log.Debugf("Querying run with id: %s", id)
-			run, _ := getRun(client, id)
+			run, err := getRun(client, id)
+			check(err)
 			workspaceID := run.Workspace.ID
 
 			// get workspaceName from run
@@ -312,6 +315,7 @@ var runCancelCmd = &cobra.Command{
 		}
 
 		force, _ := cmd.Flags().GetBool("force")
+		comment, _ := cmd.Flags().GetString("comment")
 
 		var runCancelListJson []byte
 		var runCancelList []Run
@@ -335,7 +339,8 @@ var runCancelCmd = &cobra.Command{
 			var tmpRun Run
 
 			// get workspaceid from run
-			run, _ := getRun(client, id)
+			run, err := getRun(client, id)
+			check(err)
 			workspaceID := run.Workspace.ID
 
 			// get workspacename from run
@@ -343,9 +348,11 @@ var runCancelCmd = &cobra.Command{
 
 			log.Debugf("Cancelling run with id: %s", id)
 			if force {
-				forceCancelRun(client, id)
+				err = forceCancelRun(client, id, comment)
+				check(err)
 			} else {
-				cancelRun(client, id)
+				err = cancelRun(client, id, comment)
+				check(err)
 			}
 
 			entry := fmt.Sprintf(`{
@@ -392,6 +399,8 @@ var runDiscardCmd = &cobra.Command{
 			log.Fatal("please provide one of ids or filter to perform this operation!")
 		}
 
+		comment, _ := cmd.Flags().GetString("comment")
+
 		var runDiscardListJson []byte
 		var runDiscardList []Run
 		var idList []string
@@ -414,14 +423,16 @@ var runDiscardCmd = &cobra.Command{
 			var tmpRun Run
 
 			// get workspaceid from run
-			run, _ := getRun(client, id)
+			run, err := getRun(client, id)
+			check(err)
 			workspaceID := run.Workspace.ID
 
 			// get workspacename from run
 			workspaceName, _ := getWorkspaceNameByID(client, organization, workspaceID)
 
 			log.Debugf("Discarding run with id: %s", id)
-			discardRun(client, id)
+			err = discardRun(client, id, comment)
+			check(err)
 
 			entry := fmt.Sprintf(`{
         "id":"%s",
@@ -468,6 +479,7 @@ func init() {
 
 	// Apply sub-command
 	runApplyCmd.Flags().String("ids", "", "Apply comma-separated string of runIDs")
+	runApplyCmd.Flags().String("comment", "", "Comment recorded against the apply, defaults to \"Apply run <runID>\"")
 
 	// Get sub-command
 	runGetCmd.Flags().String("ids", "", "Query comma-separated string of runIDs")
@@ -477,11 +489,14 @@ func init() {
 	runCancelCmd.Flags().String("filter", "", "Cancel run on workspaces matching filter") // Mutually exclusive with `ids`
 
 	runCancelCmd.Flags().Bool("force", false, "Force cancel comma-separated string of runIDs")
+	runCancelCmd.Flags().String("comment", "", "Comment recorded against the cancellation, defaults to \"Cancel run <runID>\"")
 
 	// Discard sub-command
 	runDiscardCmd.Flags().String("ids", "", "Discard comma-separated string of runIDs")     // Mutually exclusive with `filter`
 	runDiscardCmd.Flags().String("filter", "", "Discard run on workspaces matching filter") // Mutually exclusive with `ids`
 
+	runDiscardCmd.Flags().String("comment", "", "Comment recorded against the discard, defaults to \"Discarding run <runID>\"")
+
 }
 
 func listRuns(client *tfe.Client, workspaceID string, status string, operation string, listAll bool) ([]*tfe.Run, error) {
@@ -534,9 +549,11 @@ func queueRun(client *tfe.Client, organization string, workspace *tfe.Workspace)
 	return result, nil
 }
 
-func applyRun(client *tfe.Client, runID string) {
+func applyRun(client *tfe.Client, runID string, comment string) {
 
-	comment := fmt.Sprintf("Apply run %s", runID)
+	if comment == "" {
+		comment = fmt.Sprintf("Apply run %s", runID)
+	}
 	options := tfe.RunApplyOptions{
 		Comment: &comment,
 	}
@@ -548,45 +565,41 @@ func applyRun(client *tfe.Client, runID string) {
 
 func getRun(client *tfe.Client, runID string) (*tfe.Run, error) {
 
-	result, err := client.Runs.Read(context.Background(), runID)
-
-	check(err)
-
-	return result, nil
+	return client.Runs.Read(context.Background(), runID)
 }
 
-func cancelRun(client *tfe.Client, runID string) {
-	comment := fmt.Sprintf("Cancel run %s", runID)
+func cancelRun(client *tfe.Client, runID string, comment string) error {
+	if comment == "" {
+		comment = fmt.Sprintf("Cancel run %s", runID)
+	}
 
 	options := tfe.RunCancelOptions{
 		Comment: &comment,
 	}
 
-	err := client.Runs.Cancel(context.Background(), runID, options)
-
-	check(err)
+	return client.Runs.Cancel(context.Background(), runID, options)
 }
 
-func forceCancelRun(client *tfe.Client, runID string) {
-	comment := fmt.Sprintf("Force-cancel run %s", runID)
+func forceCancelRun(client *tfe.Client, runID string, comment string) error {
+	if comment == "" {
+		comment = fmt.Sprintf("Force-cancel run %s", runID)
+	}
 
 	options := tfe.RunForceCancelOptions{
 		Comment: &comment,
 	}
 
-	err := client.Runs.ForceCancel(context.Background(), runID, options)
-
-	check(err)
+	return client.Runs.ForceCancel(context.Background(), runID, options)
 }
 
-func discardRun(client *tfe.Client, runID string) {
-	comment := fmt.Sprintf("Discarding run %s", runID)
+func discardRun(client *tfe.Client, runID string, comment string) error {
+	if comment == "" {
+		comment = fmt.Sprintf("Discarding run %s", runID)
+	}
 
 	options := tfe.RunDiscardOptions{
 		Comment: &comment,
 	}
 
-	err := client.Runs.Discard(context.Background(), runID, options)
-
-	check(err)
+	return client.Runs.Discard(context.Background(), runID, options)
 }
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type RunComment struct {
+	ID    string `json:"id"`
+	RunID string `json:"run_id"`
+	Body  string `json:"body"`
+}
+
+var runCommentCmd = &cobra.Command{
+	Use:   "comment",
+	Short: "Manage comments on TFE runs",
+	Long:  `Manage comments on TFE runs.`,
+}
+
+var runCommentListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List comments on a TFE run",
+	Long:  `List comments on a TFE run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		runID, _ := cmd.Flags().GetString("run-id")
+
+		comments, err := listRunComments(client, runID)
+		check(err)
+
+		commentListJson, _ := json.MarshalIndent(comments, "", "  ")
+		outputData(cmd, commentListJson)
+	},
+}
+
+var runCommentAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a comment to a TFE run",
+	Long:  `Add a comment to a TFE run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		runID, _ := cmd.Flags().GetString("run-id")
+		body, _ := cmd.Flags().GetString("body")
+
+		if body == "" {
+			log.Fatal("please provide the body of the comment to perform this operation!")
+		}
+
+		comment, err := addRunComment(client, runID, body)
+		check(err)
+
+		commentJson, _ := json.MarshalIndent(comment, "", "  ")
+		outputData(cmd, commentJson)
+	},
+}
+
+func init() {
+	runCmd.AddCommand(runCommentCmd)
+
+	// List sub-command
+	runCommentCmd.AddCommand(runCommentListCmd)
+	runCommentListCmd.Flags().String("run-id", "", "RunID to list comments of")
+
+	// Add sub-command
+	runCommentCmd.AddCommand(runCommentAddCmd)
+	runCommentAddCmd.Flags().String("run-id", "", "RunID to comment on")
+	runCommentAddCmd.Flags().String("body", "", "Body of the comment")
+}
+
+func listRunComments(client *tfe.Client, runID string) ([]RunComment, error) {
+	results := []RunComment{}
+
+	log.Debugf("Retrieving comments for run: %s", runID)
+	cl, err := client.Comments.List(context.Background(), runID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range cl.Items {
+		results = append(results, RunComment{
+			ID:    c.ID,
+			RunID: runID,
+			Body:  c.Body,
+		})
+	}
+
+	return results, nil
+}
+
+func addRunComment(client *tfe.Client, runID string, body string) (RunComment, error) {
+	var result RunComment
+
+	log.Debugf("Commenting on run: %s", runID)
+	c, err := client.Comments.Create(context.Background(), runID, tfe.CommentCreateOptions{
+		Body: body,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result = RunComment{
+		ID:    c.ID,
+		RunID: runID,
+		Body:  c.Body,
+	}
+
+	return result, nil
+}
@@ -0,0 +1,509 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type PipelineResult struct {
+	Level         int    `json:"level"`
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceName string `json:"workspace_name"`
+	RunID         string `json:"run_id"`
+	Status        string `json:"status"`
+	Result        string `json:"result"`
+	Error         string `json:"error"`
+}
+
+// workspaceGraph holds the dependencies between workspaces, an edge from
+// upstream to downstream means downstream has to run after upstream.
+type workspaceGraph struct {
+	workspaces map[string]*tfe.Workspace
+	downstream map[string][]string
+	upstream   map[string][]string
+}
+
+var runPipelineCmd = &cobra.Command{
+	Use:   "pipeline",
+	Short: "Queue runs on dependent workspaces following their dependency order",
+	Long: `Queue runs on dependent workspaces following their dependency order.
+Dependencies are discovered from run triggers and remote state consumers. Runs are queued
+level by level and each level has to apply or finish before the next one starts.
+Runs awaiting confirmation or a policy override are waited on until --timeout.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		filter, _ := cmd.Flags().GetString("filter")
+		from, _ := cmd.Flags().GetString("from")
+		autoApply, _ := cmd.Flags().GetBool("auto-apply")
+		pollInterval, _ := cmd.Flags().GetDuration("poll-interval")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		workspaces, err := listWorkspaces(client, organization, filter)
+		check(err)
+
+		if len(workspaces) == 0 {
+			log.Fatalf("no workspaces match filter: %s", filter)
+		}
+
+		graph, err := buildWorkspaceGraph(client, workspaces)
+		check(err)
+
+		if from != "" {
+			graph, err = graph.descendantsOf(from)
+			check(err)
+		}
+
+		levels, err := graph.levels()
+		check(err)
+
+		results, failed := runPipeline(client, graph, levels, autoApply, pollInterval, timeout)
+
+		resultsJson, _ := json.MarshalIndent(results, "", "  ")
+		outputData(cmd, resultsJson)
+
+		if failed {
+			log.Fatal("pipeline stopped after a failed run")
+		}
+	},
+}
+
+func init() {
+	runCmd.AddCommand(runPipelineCmd)
+
+	// Pipeline sub-command
+	runPipelineCmd.Flags().String("filter", "", "Workspaces taking part in the pipeline\nTo filter by tag, prefix filter with \"tags|\"")
+	runPipelineCmd.Flags().String("from", "", "Name or ID of the workspace to start from, only it and its downstream workspaces are run")
+	runPipelineCmd.Flags().Bool("auto-apply", false, "Apply the queued runs without confirmation")
+	runPipelineCmd.Flags().Duration("poll-interval", 10*time.Second, "Interval between run status checks")
+	runPipelineCmd.Flags().Duration("timeout", time.Hour, "Maximum time to wait on each run")
+}
+
+func buildWorkspaceGraph(client *tfe.Client, workspaces []*tfe.Workspace) (*workspaceGraph, error) {
+	graph := &workspaceGraph{
+		workspaces: map[string]*tfe.Workspace{},
+		downstream: map[string][]string{},
+		upstream:   map[string][]string{},
+	}
+
+	for _, workspace := range workspaces {
+		graph.workspaces[workspace.ID] = workspace
+	}
+
+	for _, workspace := range workspaces {
+		log.Debugf("Processing dependencies of workspace: %s - %s", workspace.Name, workspace.ID)
+
+		// Run triggers pointing at this workspace make it downstream of their source
+		triggers, err := listRunTriggers(client, workspace.ID, tfe.RunTriggerInbound)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, trigger := range triggers {
+			source := trigger.Sourceable
+			if trigger.SourceableChoice != nil && trigger.SourceableChoice.Workspace != nil {
+				source = trigger.SourceableChoice.Workspace
+			}
+			if source != nil {
+				graph.addEdge(source.ID, workspace.ID)
+			}
+		}
+
+		// Remote state consumers of this workspace are downstream of it
+		if workspace.GlobalRemoteState {
+			continue
+		}
+
+		consumers, err := listRemoteStateConsumers(client, workspace.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, consumer := range consumers {
+			graph.addEdge(workspace.ID, consumer.ID)
+		}
+	}
+
+	return graph, nil
+}
+
+func (g *workspaceGraph) addEdge(from string, to string) {
+	// Only workspaces taking part in the pipeline are considered
+	if _, ok := g.workspaces[from]; !ok {
+		return
+	}
+	if _, ok := g.workspaces[to]; !ok {
+		return
+	}
+
+	for _, id := range g.downstream[from] {
+		if id == to {
+			return
+		}
+	}
+
+	log.Debugf("Adding dependency %s -> %s", g.workspaces[from].Name, g.workspaces[to].Name)
+	g.downstream[from] = append(g.downstream[from], to)
+	g.upstream[to] = append(g.upstream[to], from)
+}
+
+// descendantsOf returns the sub-graph made of the given workspace and every
+// workspace downstream of it.
+func (g *workspaceGraph) descendantsOf(nameOrID string) (*workspaceGraph, error) {
+	var start string
+	for id, workspace := range g.workspaces {
+		if id == nameOrID || workspace.Name == nameOrID {
+			start = id
+			break
+		}
+	}
+
+	if start == "" {
+		return nil, fmt.Errorf("workspace %s does not match the filter", nameOrID)
+	}
+
+	keep := map[string]bool{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if keep[id] {
+			continue
+		}
+		keep[id] = true
+		queue = append(queue, g.downstream[id]...)
+	}
+
+	result := &workspaceGraph{
+		workspaces: map[string]*tfe.Workspace{},
+		downstream: map[string][]string{},
+		upstream:   map[string][]string{},
+	}
+
+	for id := range keep {
+		result.workspaces[id] = g.workspaces[id]
+	}
+
+	for from, tos := range g.downstream {
+		for _, to := range tos {
+			result.addEdge(from, to)
+		}
+	}
+
+	return result, nil
+}
+
+// levels orders the workspaces so that every workspace sits one level below
+// the deepest of its upstream workspaces.
+func (g *workspaceGraph) levels() ([][]string, error) {
+	var results [][]string
+
+	remaining := map[string]int{}
+	for id := range g.workspaces {
+		remaining[id] = len(g.upstream[id])
+	}
+
+	for len(remaining) > 0 {
+		var level []string
+		for id, count := range remaining {
+			if count == 0 {
+				level = append(level, id)
+			}
+		}
+
+		if len(level) == 0 {
+			return nil, fmt.Errorf("dependency cycle detected between workspaces")
+		}
+
+		sort.Slice(level, func(i, j int) bool {
+			return g.workspaces[level[i]].Name < g.workspaces[level[j]].Name
+		})
+
+		for _, id := range level {
+			delete(remaining, id)
+			for _, to := range g.downstream[id] {
+				remaining[to]--
+			}
+		}
+
+		results = append(results, level)
+	}
+
+	return results, nil
+}
+
+func runPipeline(client *tfe.Client, graph *workspaceGraph, levels [][]string, autoApply bool, pollInterval time.Duration, timeout time.Duration) ([]PipelineResult, bool) {
+	var results []PipelineResult
+	failed := false
+	pipelineStart := time.Now()
+
+	for n, level := range levels {
+		levelStart := time.Now()
+		runs := make([]*tfe.Run, len(level))
+		var levelResults []PipelineResult
+
+		for i, id := range level {
+			workspace := graph.workspaces[id]
+			result := PipelineResult{
+				Level:         n,
+				WorkspaceID:   workspace.ID,
+				WorkspaceName: workspace.Name,
+				Result:        "skipped",
+			}
+
+			if failed {
+				levelResults = append(levelResults, result)
+				continue
+			}
+
+			// Only downstream workspaces can have runs created by run triggers
+			var upstreamNames []string
+			if n > 0 {
+				for _, upstream := range graph.upstream[id] {
+					upstreamNames = append(upstreamNames, graph.workspaces[upstream].Name)
+				}
+			}
+
+			run, err := pipelineQueueRun(client, workspace, autoApply, pipelineStart, upstreamNames)
+			if err != nil {
+				log.Errorf("Unable to queue a run on %s: %v", workspace.Name, err)
+				result.Result = "failed"
+				result.Error = err.Error()
+				levelResults = append(levelResults, result)
+				failed = true
+				continue
+			}
+
+			result.RunID = run.ID
+			result.Status = string(run.Status)
+
+			runs[i] = run
+			levelResults = append(levelResults, result)
+		}
+
+		log.Infof("Waiting on the runs of level %d", n)
+
+		for i, run := range runs {
+			if run == nil {
+				continue
+			}
+
+			run, err := waitForRun(client, run.ID, pollInterval, timeout)
+			if run != nil {
+				levelResults[i].Status = string(run.Status)
+				levelResults[i].Result = pipelineRunResult(run)
+			}
+			if err != nil {
+				levelResults[i].Result = "failed"
+				levelResults[i].Error = err.Error()
+			}
+
+			if levelResults[i].Result != "success" {
+				failed = true
+			}
+		}
+
+		log.Infof("Level %d completed in %s", n, time.Since(levelStart).Round(time.Second))
+
+		results = append(results, levelResults...)
+	}
+
+	return results, failed
+}
+
+// pipelineQueueRun queues a run on the workspace. A run trigger may already
+// have created a run on a downstream workspace since the pipeline started:
+// such runs name their source workspace in their message, the oldest one
+// still in progress is followed instead of queuing a second run.
+func pipelineQueueRun(client *tfe.Client, workspace *tfe.Workspace, autoApply bool, pipelineStart time.Time, upstreamNames []string) (*tfe.Run, error) {
+	if len(upstreamNames) > 0 {
+		runs, err := listRuns(client, workspace.ID, "", "", false)
+		if err != nil {
+			return nil, err
+		}
+
+		if run := findTriggeredRun(runs, pipelineStart, upstreamNames); run != nil {
+			log.Debugf("Following run %s triggered on %s", run.ID, workspace.Name)
+			return run, nil
+		}
+	}
+
+	message := fmt.Sprintf("Queue plan on %s by tfectl pipeline", workspace.Name)
+	options := tfe.RunCreateOptions{
+		Message:   &message,
+		Workspace: workspace,
+	}
+
+	if autoApply {
+		options.AutoApply = &autoApply
+	}
+
+	log.Debugf("Queuing run on %s", workspace.Name)
+	return client.Runs.Create(context.Background(), options)
+}
+
+// findTriggeredRun returns the oldest run created since the given time, still
+// in progress and whose message names one of the upstream workspaces as a
+// whole word, such as "Triggered by workspace org/network"
+func findTriggeredRun(runs []*tfe.Run, since time.Time, upstreamNames []string) *tfe.Run {
+	var result *tfe.Run
+
+	for _, run := range runs {
+		if !run.CreatedAt.After(since) || isFinalRunStatus(run.Status) {
+			continue
+		}
+
+		for _, name := range upstreamNames {
+			if messageNamesWorkspace(run.Message, name) {
+				if result == nil || run.CreatedAt.Before(result.CreatedAt) {
+					result = run
+				}
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// messageNamesWorkspace reports whether the message contains the workspace
+// name without other workspace name characters around it, so that net
+// doesn't match network or net-prod
+func messageNamesWorkspace(message string, name string) bool {
+	isNameChar := func(c byte) bool {
+		return c == '-' || c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+	}
+
+	for start := 0; name != ""; {
+		i := strings.Index(message[start:], name)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(name)
+
+		if (i == 0 || !isNameChar(message[i-1])) && (end == len(message) || !isNameChar(message[end])) {
+			return true
+		}
+		start = i + 1
+	}
+
+	return false
+}
+
+// waitForRun polls the run until it reaches a final status. Runs awaiting a
+// confirmation or a policy override are waited on until the timeout.
+func waitForRun(client *tfe.Client, runID string, pollInterval time.Duration, timeout time.Duration) (*tfe.Run, error) {
+	deadline := time.Now().Add(timeout)
+	var lastStatus tfe.RunStatus
+
+	for {
+		run, err := client.Runs.Read(context.Background(), runID)
+		if err != nil {
+			return nil, err
+		}
+
+		if run.Status != lastStatus {
+			log.Debugf("Run %s is %s", run.ID, run.Status)
+
+			if run.Actions != nil && run.Actions.IsConfirmable {
+				log.Infof("Run %s is awaiting confirmation", run.ID)
+			}
+			if run.Status == tfe.RunPolicySoftFailed {
+				log.Infof("Run %s is awaiting a policy override", run.ID)
+			}
+
+			lastStatus = run.Status
+		}
+
+		if isFinalRunStatus(run.Status) {
+			return run, nil
+		}
+
+		if time.Now().After(deadline) {
+			return run, fmt.Errorf("timed out after %s waiting on run %s", timeout, runID)
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+func pipelineRunResult(run *tfe.Run) string {
+	switch run.Status {
+	case tfe.RunApplied, tfe.RunPlannedAndFinished:
+		return "success"
+	}
+
+	return "failed"
+}
+
+func listRunTriggers(client *tfe.Client, workspaceID string, triggerType tfe.RunTriggerFilterOp) ([]*tfe.RunTrigger, error) {
+	results := []*tfe.RunTrigger{}
+	currentPage := 1
+
+	for {
+		log.Debugf("Processing page %d.\n", currentPage)
+		options := &tfe.RunTriggerListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: currentPage,
+				PageSize:   100,
+			},
+			RunTriggerType: triggerType,
+		}
+
+		rt, err := client.RunTriggers.List(context.Background(), workspaceID, options)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rt.Items...)
+
+		if rt.NextPage == 0 {
+			break
+		}
+
+		currentPage++
+	}
+
+	return results, nil
+}
+
+func listRemoteStateConsumers(client *tfe.Client, workspaceID string) ([]*tfe.Workspace, error) {
+	results := []*tfe.Workspace{}
+	currentPage := 1
+
+	for {
+		log.Debugf("Processing page %d.\n", currentPage)
+		options := &tfe.RemoteStateConsumersListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: currentPage,
+				PageSize:   100,
+			},
+		}
+
+		w, err := client.Workspaces.ListRemoteStateConsumers(context.Background(), workspaceID, options)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, w.Items...)
+
+		if w.NextPage == 0 {
+			break
+		}
+
+		currentPage++
+	}
+
+	return results, nil
+}
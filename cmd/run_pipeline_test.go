@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageNamesWorkspace(t *testing.T) {
+	tt := []struct {
+		message string
+		name    string
+		want    bool
+	}{
+		{"Triggered by workspace org/net", "net", true},
+		{"Triggered by workspace org/net, run run-1", "net", true},
+		{"net", "net", true},
+		{"Triggered by workspace org/network", "net", false},
+		{"Triggered by workspace org/net-prod", "net", false},
+		{"Triggered by workspace org/sub_net", "net", false},
+		{"Triggered by workspace org/network and org/net", "net", true},
+		{"Triggered by workspace org/network", "", false},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.want, messageNamesWorkspace(tc.message, tc.name), "%s in %s", tc.name, tc.message)
+	}
+}
+
+func TestFindTriggeredRun(t *testing.T) {
+	since := time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)
+	run := func(id string, minutes int, status tfe.RunStatus, message string) *tfe.Run {
+		return &tfe.Run{ID: id, CreatedAt: since.Add(time.Duration(minutes) * time.Minute), Status: status, Message: message}
+	}
+
+	tt := []struct {
+		name string
+		runs []*tfe.Run
+		want string
+	}{
+		{
+			name: "oldest matching run",
+			runs: []*tfe.Run{
+				run("run-2", 2, tfe.RunPlanning, "Triggered by workspace org/net"),
+				run("run-1", 1, tfe.RunPending, "Triggered by workspace org/net"),
+			},
+			want: "run-1",
+		},
+		{
+			name: "other workspace with a longer name",
+			runs: []*tfe.Run{
+				run("run-1", 1, tfe.RunPending, "Triggered by workspace org/network"),
+				run("run-2", 2, tfe.RunPending, "Triggered by workspace org/net"),
+			},
+			want: "run-2",
+		},
+		{
+			name: "runs before the pipeline and finished runs are ignored",
+			runs: []*tfe.Run{
+				run("run-1", -1, tfe.RunPending, "Triggered by workspace org/net"),
+				run("run-2", 1, tfe.RunApplied, "Triggered by workspace org/net"),
+			},
+		},
+		{
+			name: "no upstream named",
+			runs: []*tfe.Run{run("run-1", 1, tfe.RunPending, "Queued manually")},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := findTriggeredRun(tc.runs, since, []string{"net", "dns"})
+
+			if tc.want == "" {
+				require.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			require.Equal(t, tc.want, got.ID)
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Run statuses in which a run is either waiting in a queue or waiting on a
+// confirmation, blocking later runs of the workspace.
+var stuckRunStatuses = []tfe.RunStatus{
+	tfe.RunPending,
+	tfe.RunPlanQueued,
+	tfe.RunPlanned,
+	tfe.RunCostEstimated,
+	tfe.RunPolicyChecked,
+	tfe.RunPolicyOverride,
+	tfe.RunPolicySoftFailed,
+	tfe.RunPostPlanCompleted,
+	tfe.RunApplyQueued,
+}
+
+type StuckRun struct {
+	ID            string `json:"id"`
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceName string `json:"workspace_name"`
+	Status        string `json:"status"`
+	CreatedAt     string `json:"created_at"`
+	StatusSince   string `json:"status_since"`
+	AgeHours      string `json:"age_hours"`
+	Action        string `json:"action,omitempty"`
+	DryRun        bool   `json:"dry_run,omitempty"`
+	Error         string `json:"error,omitempty"`
+
+	run *tfe.Run
+}
+
+var runStuckCmd = &cobra.Command{
+	Use:   "stuck",
+	Short: "Find runs stuck in a queue or awaiting confirmation",
+	Long:  `Find runs stuck in a queue or awaiting confirmation across workspaces.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		filter, all, olderThan := getStuckRunFlags(cmd)
+
+		stuckRuns, err := findStuckRuns(client, organization, filter, all, olderThan)
+		check(err)
+
+		stuckRunsJson, _ := json.MarshalIndent(stuckRuns, "", "  ")
+		outputData(cmd, stuckRunsJson)
+	},
+}
+
+var runCleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Discard or cancel runs stuck in a queue or awaiting confirmation",
+	Long: `Discard or cancel runs stuck in a queue or awaiting confirmation across workspaces.
+Runs only when --dry-run=false is set, otherwise lists the action that would be taken on each run.
+A failure on one run doesn't stop the others, it's reported in the error of that run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		filter, all, olderThan := getStuckRunFlags(cmd)
+		action, _ := cmd.Flags().GetString("action")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if action != "auto" && action != "discard" && action != "cancel" {
+			log.Fatalf("unsupported action %s, use one of auto, discard or cancel", action)
+		}
+
+		stuckRuns, err := findStuckRuns(client, organization, filter, all, olderThan)
+		check(err)
+
+		failed := 0
+		for i, stuckRun := range stuckRuns {
+			stuckRuns[i].Action = cleanupAction(stuckRun.run, action)
+			stuckRuns[i].DryRun = dryRun
+
+			if dryRun {
+				continue
+			}
+
+			comment := fmt.Sprintf("Cleaned up by tfectl, run was %s for %s hours", stuckRun.Status, stuckRun.AgeHours)
+
+			var err error
+			switch stuckRuns[i].Action {
+			case "discard":
+				log.Debugf("Discarding run with id: %s", stuckRun.ID)
+				err = discardRun(client, stuckRun.ID, comment)
+			case "cancel":
+				log.Debugf("Cancelling run with id: %s", stuckRun.ID)
+				err = cancelRun(client, stuckRun.ID, comment)
+			default:
+				log.Warnf("Run %s on %s can not be discarded or cancelled", stuckRun.ID, stuckRun.WorkspaceName)
+			}
+
+			if err != nil {
+				stuckRuns[i].Error = err.Error()
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			log.Warnf("%d of %d runs could not be cleaned up", failed, len(stuckRuns))
+		}
+
+		stuckRunsJson, _ := json.MarshalIndent(stuckRuns, "", "  ")
+		outputData(cmd, stuckRunsJson)
+	},
+}
+
+func init() {
+	runCmd.AddCommand(runStuckCmd)
+	runCmd.AddCommand(runCleanupCmd)
+
+	for _, c := range []*cobra.Command{runStuckCmd, runCleanupCmd} {
+		c.Flags().String("filter", "", "Search workspaces matching filter")       // Mutually exclusive with `all`
+		c.Flags().Bool("all", false, "Search all workspaces of the organization") // Mutually exclusive with `filter`
+		c.Flags().Duration("older-than", 24*time.Hour, "Only return runs created longer ago than this duration")
+	}
+
+	// Cleanup sub-command
+	runCleanupCmd.Flags().String("action", "auto", "Action to take on stuck runs: discard, cancel or auto\nauto discards runs awaiting confirmation and cancels queued runs")
+	runCleanupCmd.Flags().Bool("dry-run", true, "Only list the action that would be taken on each run")
+}
+
+func getStuckRunFlags(cmd *cobra.Command) (string, bool, time.Duration) {
+	filter, _ := cmd.Flags().GetString("filter")
+	all, _ := cmd.Flags().GetBool("all")
+	olderThan, _ := cmd.Flags().GetDuration("older-than")
+
+	if filter != "" && all {
+		log.Fatal("filter and all are mutually exclusive, use one or the other!")
+	}
+
+	if filter == "" && !all {
+		log.Fatal("please provide one of filter or all to perform this operation!")
+	}
+
+	return filter, all, olderThan
+}
+
+func findStuckRuns(client *tfe.Client, organization string, filter string, all bool, olderThan time.Duration) ([]StuckRun, error) {
+	results := []StuckRun{}
+
+	if all {
+		filter = ""
+	}
+
+	workspaces, err := listWorkspaces(client, organization, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []string
+	for _, status := range stuckRunStatuses {
+		statuses = append(statuses, string(status))
+	}
+
+	for _, workspace := range workspaces {
+		log.Debugf("Processing workspace: %s - %s", workspace.Name, workspace.ID)
+
+		runs, err := listRuns(client, workspace.ID, strings.Join(statuses, ","), "", true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, run := range runs {
+			statusSince := runStatusSince(run)
+			age := time.Since(statusSince)
+			if age < olderThan {
+				continue
+			}
+
+			results = append(results, StuckRun{
+				ID:            run.ID,
+				WorkspaceID:   workspace.ID,
+				WorkspaceName: workspace.Name,
+				Status:        string(run.Status),
+				CreatedAt:     run.CreatedAt.Format(time.RFC3339),
+				StatusSince:   statusSince.Format(time.RFC3339),
+				AgeHours:      fmt.Sprintf("%f", age.Hours()),
+				run:           run,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// runStatusSince returns when the run entered its current status, or when it
+// was created when the status timestamp isn't known
+func runStatusSince(run *tfe.Run) time.Time {
+	if run.StatusTimestamps == nil {
+		return run.CreatedAt
+	}
+
+	ts := run.StatusTimestamps
+	var since time.Time
+
+	switch run.Status {
+	case tfe.RunPlanQueued:
+		since = ts.PlanQueuedAt
+	case tfe.RunPlanned:
+		since = ts.PlannedAt
+	case tfe.RunCostEstimated:
+		since = ts.CostEstimatedAt
+	case tfe.RunPolicyChecked:
+		since = ts.PolicyCheckedAt
+	case tfe.RunPolicyOverride, tfe.RunPolicySoftFailed:
+		since = ts.PolicySoftFailedAt
+	case tfe.RunPostPlanCompleted:
+		since = ts.PostPlanCompletedAt
+	case tfe.RunApplyQueued:
+		since = ts.ApplyQueuedAt
+	}
+
+	if since.IsZero() {
+		return run.CreatedAt
+	}
+
+	return since
+}
+
+// cleanupAction returns the action allowed on the run for the requested
+// action, or "none" when the run can't be discarded or cancelled.
+func cleanupAction(run *tfe.Run, action string) string {
+	var discardable, cancelable bool
+	if run.Actions != nil {
+		discardable = run.Actions.IsDiscardable
+		cancelable = run.Actions.IsCancelable
+	}
+
+	switch {
+	case (action == "auto" || action == "discard") && discardable:
+		return "discard"
+	case (action == "auto" || action == "cancel") && cancelable:
+		return "cancel"
+	}
+
+	return "none"
+}
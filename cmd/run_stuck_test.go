@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStatusSince(t *testing.T) {
+	created := time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)
+	planned := created.Add(3 * time.Hour)
+
+	tt := []struct {
+		name   string
+		status tfe.RunStatus
+		ts     *tfe.RunStatusTimestamps
+		want   time.Time
+	}{
+		{"current status", tfe.RunPlanned, &tfe.RunStatusTimestamps{PlanQueuedAt: created, PlannedAt: planned}, planned},
+		{"policy override", tfe.RunPolicyOverride, &tfe.RunStatusTimestamps{PolicySoftFailedAt: planned}, planned},
+		{"missing timestamp", tfe.RunApplyQueued, &tfe.RunStatusTimestamps{PlannedAt: planned}, created},
+		{"pending", tfe.RunPending, &tfe.RunStatusTimestamps{}, created},
+		{"no timestamps", tfe.RunPlanned, nil, created},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			run := &tfe.Run{Status: tc.status, CreatedAt: created, StatusTimestamps: tc.ts}
+			require.Equal(t, tc.want, runStatusSince(run))
+		})
+	}
+}
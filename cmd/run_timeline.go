@@ -0,0 +1,422 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Width, in characters, of the bar section of the gantt chart
+const timelineChartWidth = 60
+
+type RunTimelineEntry struct {
+	Phase       string `json:"phase"`
+	Source      string `json:"source"`
+	StartedAt   string `json:"started_at"`
+	EndedAt     string `json:"ended_at"`
+	Duration    string `json:"duration"`
+	Description string `json:"description"`
+
+	start time.Time
+	end   time.Time
+}
+
+type RunTimeline struct {
+	ID            string             `json:"id"`
+	WorkspaceID   string             `json:"workspace_id"`
+	WorkspaceName string             `json:"workspace_name"`
+	Status        string             `json:"status"`
+	CreatedAt     string             `json:"created_at"`
+	RunDuration   string             `json:"run_duration"`
+	Timeline      []RunTimelineEntry `json:"timeline"`
+}
+
+var runTimelineCmd = &cobra.Command{
+	Use:   "timeline",
+	Short: "Show the timeline of a run with the duration of each phase",
+	Long:  `Show the timeline of a run with the duration of each phase.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+		format, _ := cmd.Flags().GetString("format")
+
+		if id == "" {
+			log.Fatal("please provide the id of the run to perform this operation!")
+		}
+
+		timeline, err := getRunTimeline(client, organization, id)
+		check(err)
+
+		switch format {
+		case "gantt":
+			cmd.Println(renderTimelineGantt(timeline))
+		case "json":
+			timelineJson, _ := json.MarshalIndent(timeline, "", "  ")
+			outputData(cmd, timelineJson)
+		default:
+			log.Fatalf("unsupported format %s, use one of json or gantt", format)
+		}
+	},
+}
+
+func init() {
+	runCmd.AddCommand(runTimelineCmd)
+
+	// Timeline sub-command
+	runTimelineCmd.Flags().String("id", "", "RunID to build the timeline for")
+	runTimelineCmd.Flags().String("format", "json", "Output format of the timeline. Supported values are json or gantt")
+}
+
+func getRunTimeline(client *tfe.Client, organization string, runID string) (RunTimeline, error) {
+	result := RunTimeline{}
+
+	run, err := getRun(client, runID)
+	if err != nil {
+		return result, err
+	}
+
+	workspaceName, _ := getWorkspaceNameByID(client, organization, run.Workspace.ID)
+
+	result.ID = run.ID
+	result.WorkspaceID = run.Workspace.ID
+	result.WorkspaceName = workspaceName
+	result.Status = string(run.Status)
+	result.CreatedAt = run.CreatedAt.Format(time.RFC3339)
+
+	var entries []RunTimelineEntry
+
+	entries = append(entries, runStatusPhases(run)...)
+
+	taskStages, err := listRunTaskStages(client, runID)
+	if err != nil {
+		return result, err
+	}
+
+	for _, ts := range taskStages {
+		start := ts.StatusTimestamps.RunningAt
+		if start.IsZero() {
+			start = ts.CreatedAt
+		}
+		end := firstNonZeroTime(
+			ts.StatusTimestamps.PassedAt,
+			ts.StatusTimestamps.FailedAt,
+			ts.StatusTimestamps.ErroredAt,
+			ts.StatusTimestamps.CanceledAt,
+		)
+
+		entries = append(entries, newTimelineEntry(string(ts.Stage), "task_stage", start, end, string(ts.Status)))
+	}
+
+	policyChecks, err := listRunPolicyChecks(client, runID)
+	if err != nil {
+		return result, err
+	}
+
+	for _, pc := range policyChecks {
+		if pc.StatusTimestamps == nil {
+			continue
+		}
+
+		end := firstNonZeroTime(
+			pc.StatusTimestamps.PassedAt,
+			pc.StatusTimestamps.SoftFailedAt,
+			pc.StatusTimestamps.HardFailedAt,
+			pc.StatusTimestamps.ErroredAt,
+		)
+
+		entries = append(entries, newTimelineEntry("policy_check", "policy_check", pc.StatusTimestamps.QueuedAt, end, string(pc.Status)))
+	}
+
+	// Run events aren't paginated by the API
+	log.Debugf("Retrieving run events for run: %s", runID)
+	runEvents, err := client.RunEvents.List(context.Background(), runID, &tfe.RunEventListOptions{})
+	if err != nil {
+		return result, err
+	}
+
+	for _, re := range runEvents.Items {
+		entries = append(entries, newTimelineEntry(re.Action, "run_event", re.CreatedAt, re.CreatedAt, re.Description))
+	}
+
+	entries = dropUnstartedEntries(entries)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].start.Before(entries[j].start)
+	})
+
+	result.Timeline = entries
+	result.RunDuration = "NA"
+	if len(entries) > 0 {
+		result.RunDuration = fmt.Sprintf("%f", timelineEnd(entries).Sub(run.CreatedAt).Seconds())
+	}
+
+	return result, nil
+}
+
+func listRunTaskStages(client *tfe.Client, runID string) ([]*tfe.TaskStage, error) {
+	var results []*tfe.TaskStage
+	currentPage := 1
+
+	for {
+		log.Debugf("Retrieving page %d of task stages for run: %s", currentPage, runID)
+		options := &tfe.TaskStageListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: currentPage,
+				PageSize:   50,
+			},
+		}
+
+		ts, err := client.TaskStages.List(context.Background(), runID, options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, ts.Items...)
+
+		if ts.Pagination == nil || ts.NextPage == 0 {
+			break
+		}
+
+		currentPage++
+	}
+
+	return results, nil
+}
+
+func listRunPolicyChecks(client *tfe.Client, runID string) ([]*tfe.PolicyCheck, error) {
+	var results []*tfe.PolicyCheck
+	currentPage := 1
+
+	for {
+		log.Debugf("Retrieving page %d of policy checks for run: %s", currentPage, runID)
+		options := &tfe.PolicyCheckListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: currentPage,
+				PageSize:   50,
+			},
+		}
+
+		pc, err := client.PolicyChecks.List(context.Background(), runID, options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, pc.Items...)
+
+		if pc.Pagination == nil || pc.NextPage == 0 {
+			break
+		}
+
+		currentPage++
+	}
+
+	return results, nil
+}
+
+// dropUnstartedEntries removes entries without a start time, such as policy
+// checks which were never queued, as they can't be placed on the timeline
+func dropUnstartedEntries(entries []RunTimelineEntry) []RunTimelineEntry {
+	var results []RunTimelineEntry
+
+	for _, e := range entries {
+		if e.start.IsZero() {
+			log.Debugf("Skipping %s %s: it has no start time", e.Source, e.Phase)
+			continue
+		}
+		results = append(results, e)
+	}
+
+	return results
+}
+
+// runStatusPhases turns the status timestamps of a run into consecutive phases,
+// each phase lasting until the run moved on to its next status.
+func runStatusPhases(run *tfe.Run) []RunTimelineEntry {
+	var results []RunTimelineEntry
+
+	ts := run.StatusTimestamps
+	if ts == nil {
+		return results
+	}
+
+	statuses := []struct {
+		status tfe.RunStatus
+		at     time.Time
+	}{
+		{tfe.RunPending, run.CreatedAt},
+		{tfe.RunFetching, ts.FetchingAt},
+		{tfe.RunFetchingCompleted, ts.FetchedAt},
+		{tfe.RunPrePlanRunning, ts.PrePlanRunningAt},
+		{tfe.RunPrePlanCompleted, ts.PrePlanCompletedAt},
+		{tfe.RunQueuing, ts.QueuingAt},
+		{"plan_queueable", ts.PlanQueueableAt},
+		{tfe.RunPlanQueued, ts.PlanQueuedAt},
+		{tfe.RunPlanning, ts.PlanningAt},
+		{tfe.RunPlanned, ts.PlannedAt},
+		{tfe.RunCostEstimating, ts.CostEstimatingAt},
+		{tfe.RunCostEstimated, ts.CostEstimatedAt},
+		{tfe.RunPostPlanRunning, ts.PostPlanRunningAt},
+		{tfe.RunPostPlanCompleted, ts.PostPlanCompletedAt},
+		{tfe.RunPolicyChecked, ts.PolicyCheckedAt},
+		{tfe.RunPolicySoftFailed, ts.PolicySoftFailedAt},
+		{tfe.RunConfirmed, ts.ConfirmedAt},
+		{tfe.RunApplyQueued, ts.ApplyQueuedAt},
+		{tfe.RunApplying, ts.ApplyingAt},
+		{tfe.RunApplied, ts.AppliedAt},
+		{tfe.RunPlannedAndFinished, ts.PlannedAndFinishedAt},
+		{tfe.RunPlannedAndSaved, ts.PlannedAndSavedAt},
+		{tfe.RunDiscarded, ts.DiscardedAt},
+		{tfe.RunErrored, ts.ErroredAt},
+		{tfe.RunCanceled, ts.CanceledAt},
+		{"force_canceled", ts.ForceCanceledAt},
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].at.Before(statuses[j].at)
+	})
+
+	var reached []int
+	for i, s := range statuses {
+		if !s.at.IsZero() {
+			reached = append(reached, i)
+		}
+	}
+
+	for n, i := range reached {
+		s := statuses[i]
+		var end time.Time
+
+		if n+1 < len(reached) {
+			end = statuses[reached[n+1]].at
+		} else if !isFinalRunStatus(s.status) {
+			// The run is still sitting in this status
+			end = time.Now()
+		} else {
+			end = s.at
+		}
+
+		results = append(results, newTimelineEntry(string(s.status), "status", s.at, end, ""))
+	}
+
+	return results
+}
+
+func isFinalRunStatus(status tfe.RunStatus) bool {
+	switch status {
+	case tfe.RunApplied, tfe.RunPlannedAndFinished, tfe.RunPlannedAndSaved,
+		tfe.RunDiscarded, tfe.RunErrored, tfe.RunCanceled, "force_canceled":
+		return true
+	}
+
+	return false
+}
+
+func newTimelineEntry(phase string, source string, start time.Time, end time.Time, description string) RunTimelineEntry {
+	entry := RunTimelineEntry{
+		Phase:       phase,
+		Source:      source,
+		StartedAt:   start.Format(time.RFC3339),
+		Duration:    "NA",
+		Description: description,
+		start:       start,
+		end:         end,
+	}
+
+	if !end.IsZero() {
+		entry.EndedAt = end.Format(time.RFC3339)
+		entry.Duration = fmt.Sprintf("%f", end.Sub(start).Seconds())
+	}
+
+	return entry
+}
+
+func firstNonZeroTime(times ...time.Time) time.Time {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func timelineEnd(entries []RunTimelineEntry) time.Time {
+	var end time.Time
+	for _, e := range entries {
+		if e.end.After(end) {
+			end = e.end
+		}
+		if e.start.After(end) {
+			end = e.start
+		}
+	}
+
+	return end
+}
+
+// renderTimelineGantt draws the timeline as a text gantt chart, scaled so that
+// the whole run fits into timelineChartWidth characters.
+func renderTimelineGantt(timeline RunTimeline) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Run %s (%s) - %s\n", timeline.ID, timeline.WorkspaceName, timeline.Status)
+
+	if len(timeline.Timeline) == 0 {
+		return sb.String()
+	}
+
+	begin := timeline.Timeline[0].start
+	span := timelineEnd(timeline.Timeline).Sub(begin)
+	if span <= 0 {
+		span = time.Second
+	}
+
+	labelWidth := 0
+	for _, e := range timeline.Timeline {
+		label := fmt.Sprintf("%s:%s", e.Source, e.Phase)
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
+
+	for _, e := range timeline.Timeline {
+		offset := int(float64(e.start.Sub(begin)) / float64(span) * timelineChartWidth)
+		length := 1
+		if !e.end.IsZero() {
+			length = int(float64(e.end.Sub(e.start)) / float64(span) * timelineChartWidth)
+		}
+		if length < 1 {
+			length = 1
+		}
+		if offset >= timelineChartWidth {
+			offset = timelineChartWidth - 1
+		}
+		if offset+length > timelineChartWidth {
+			length = timelineChartWidth - offset
+		}
+
+		bar := strings.Repeat(" ", offset) + strings.Repeat("#", length) + strings.Repeat(" ", timelineChartWidth-offset-length)
+
+		duration := "NA"
+		if !e.end.IsZero() {
+			duration = e.end.Sub(e.start).Round(time.Second).String()
+		}
+
+		fmt.Fprintf(&sb, "%-*s |%s| %s\n", labelWidth, fmt.Sprintf("%s:%s", e.Source, e.Phase), bar, duration)
+	}
+
+	fmt.Fprintf(&sb, "%-*s  total %s", labelWidth, "", span.Round(time.Second).String())
+
+	return sb.String()
+}
@@ -59,43 +59,7 @@ var variableListCmd = &cobra.Command{
 		organization, client, err := resources.Setup(cmd)
 		check(err)
 
-		workspaceIds, _ := cmd.Flags().GetString("workspace-ids")
-		workspaceFilter, _ := cmd.Flags().GetString("workspace-filter")
-
-		if workspaceFilter != "" && workspaceIds != "" {
-			log.Fatal("workspace-filter and workspace-ids are mutually exclusive, use one or the other!")
-		}
-
-		if workspaceFilter == "" && workspaceIds == "" {
-			log.Fatal("please provide one of workspace-ids or workspace-filter to perform this operation!")
-		}
-
-		var workspaceList []WorkspaceLite
-		var tmpWorkspace WorkspaceLite
-
-		if workspaceFilter != "" {
-			workspaces, err := listWorkspaces(client, organization, workspaceFilter)
-			check(err)
-
-			for _, workspace := range workspaces {
-				tmpWorkspace.WorkspaceID = workspace.ID
-				tmpWorkspace.WorkspaceName = workspace.Name
-
-				workspaceList = append(workspaceList, tmpWorkspace)
-			}
-		}
-
-		if workspaceIds != "" {
-			workspaceIdList := strings.Split(workspaceIds, ",")
-			for _, id := range workspaceIdList {
-				workspaceName, err := getWorkspaceNameByID(client, organization, id)
-				check(err)
-				tmpWorkspace.WorkspaceID = id
-				tmpWorkspace.WorkspaceName = workspaceName
-
-				workspaceList = append(workspaceList, tmpWorkspace)
-			}
-		}
+		workspaceList := getVariableWorkspaces(cmd, client, organization)
 
 		var workspaceVarsListJson []byte
 		var workspaceVarsList []WorkspaceVars
@@ -158,6 +122,9 @@ var variableCreateCmd = &cobra.Command{
 
 		categoryType := tfe.CategoryType(categoryTypeStr)
 
+		err = resolveVariableSource(&value, &sensitive)
+		check(err)
+
 		v, err := createVariable(client, workspaceID, &key, &value, &description, &categoryType, &hcl, &sensitive)
 		check(err)
 
@@ -187,6 +154,9 @@ var variableCreateFromFileCmd = &cobra.Command{
 		check(err)
 
 		for _, newVar := range variables.Variables {
+			err = resolveVariableSource(&newVar.Value, &newVar.Sensitive)
+			check(err)
+
 			v, err := createVariable(client, workspaceID, &newVar.Key, &newVar.Value, &newVar.Description, &newVar.Category, &newVar.HCL, &newVar.Sensitive)
 			check(err)
 			outputVariablesList = append(outputVariablesList, v)
@@ -210,10 +180,19 @@ var variableUpdateCmd = &cobra.Command{
 		key, _ := cmd.Flags().GetString("key")
 		value, _ := cmd.Flags().GetString("value")
 		description, _ := cmd.Flags().GetString("description")
+		categoryTypeStr, _ := cmd.Flags().GetString("type")
 		hcl, _ := cmd.Flags().GetBool("hcl")
 		sensitive, _ := cmd.Flags().GetBool("sensitive")
 
-		v, err := updateVariable(client, workspaceID, variableID, &key, &value, &description, &hcl, &sensitive)
+		var categoryType *tfe.CategoryType
+		if cmd.Flags().Changed("type") {
+			categoryType = (*tfe.CategoryType)(&categoryTypeStr)
+		}
+
+		err = resolveVariableSource(&value, &sensitive)
+		check(err)
+
+		v, err := updateVariable(client, workspaceID, variableID, &key, &value, &description, categoryType, &hcl, &sensitive)
 		check(err)
 
 		variableJson, _ := json.MarshalIndent(v, "", "  ")
@@ -242,7 +221,15 @@ var variableUpdateFromFileCmd = &cobra.Command{
 		check(err)
 
 		for _, newVar := range variables.Variables {
-			v, err := updateVariable(client, workspaceID, newVar.ID, &newVar.Key, &newVar.Value, &newVar.Description, &newVar.HCL, &newVar.Sensitive)
+			err = resolveVariableSource(&newVar.Value, &newVar.Sensitive)
+			check(err)
+
+			var categoryType *tfe.CategoryType
+			if newVar.Category != "" {
+				categoryType = &newVar.Category
+			}
+
+			v, err := updateVariable(client, workspaceID, newVar.ID, &newVar.Key, &newVar.Value, &newVar.Description, categoryType, &newVar.HCL, &newVar.Sensitive)
 			check(err)
 			outputVariablesList = append(outputVariablesList, v)
 		}
@@ -319,6 +306,7 @@ func init() {
 	variableUpdateCmd.Flags().String("value", "", "Variable Value")
 	variableUpdateCmd.Flags().Bool("sensitive", false, "Set sensitive flag for variable")
 	variableUpdateCmd.Flags().Bool("hcl", false, "Set if variable has HCL syntax")
+	variableUpdateCmd.Flags().String("type", "", "Variable type, unchanged unless given")
 	variableUpdateCmd.Flags().String("description", "Variable Updated by tfectl", "Description for the variable")
 	// Update from file sub-command
 	variableUpdateCmd.AddCommand(variableUpdateFromFileCmd)
@@ -332,8 +320,53 @@ func init() {
 
 }
 
+// getVariableWorkspaces resolves the workspace-ids or workspace-filter flags to a list of workspaces
+func getVariableWorkspaces(cmd *cobra.Command, client *tfe.Client, organization string) []WorkspaceLite {
+	workspaceIds, _ := cmd.Flags().GetString("workspace-ids")
+	workspaceFilter, _ := cmd.Flags().GetString("workspace-filter")
+
+	if workspaceFilter != "" && workspaceIds != "" {
+		log.Fatal("workspace-filter and workspace-ids are mutually exclusive, use one or the other!")
+	}
+
+	if workspaceFilter == "" && workspaceIds == "" {
+		log.Fatal("please provide one of workspace-ids or workspace-filter to perform this operation!")
+	}
+
+	var workspaceList []WorkspaceLite
+	var tmpWorkspace WorkspaceLite
+
+	if workspaceFilter != "" {
+		workspaces, err := listWorkspaces(client, organization, workspaceFilter)
+		check(err)
+
+		for _, workspace := range workspaces {
+			tmpWorkspace.WorkspaceID = workspace.ID
+			tmpWorkspace.WorkspaceName = workspace.Name
+
+			workspaceList = append(workspaceList, tmpWorkspace)
+		}
+	}
+
+	if workspaceIds != "" {
+		workspaceIdList := strings.Split(workspaceIds, ",")
+		for _, id := range workspaceIdList {
+			workspaceName, err := getWorkspaceNameByID(client, organization, id)
+			check(err)
+			tmpWorkspace.WorkspaceID = id
+			tmpWorkspace.WorkspaceName = workspaceName
+
+			workspaceList = append(workspaceList, tmpWorkspace)
+		}
+	}
+
+	return workspaceList
+}
+
 func listVariables(client *tfe.Client, workspace WorkspaceLite) (WorkspaceVars, error) {
-	result := WorkspaceVars{}
+	result := WorkspaceVars{
+		WorkspaceLite: workspace,
+	}
 	currentPage := 1
 
 	for {
@@ -346,9 +379,10 @@ func listVariables(client *tfe.Client, workspace WorkspaceLite) (WorkspaceVars,
 		}
 
 		varList, err := client.Variables.List(context.Background(), workspace.WorkspaceID, options)
-		check(err)
+		if err != nil {
+			return WorkspaceVars{}, err
+		}
 
-		var tmpVarList []Variable
 		for _, v := range varList.Items {
 			var tmpVar = Variable{
 				ID:          v.ID,
@@ -360,12 +394,7 @@ func listVariables(client *tfe.Client, workspace WorkspaceLite) (WorkspaceVars,
 				Sensitive:   v.Sensitive,
 			}
 
-			tmpVarList = append(tmpVarList, tmpVar)
-		}
-
-		result = WorkspaceVars{
-			WorkspaceLite: workspace,
-			Variables:     tmpVarList,
+			result.Variables = append(result.Variables, tmpVar)
 		}
 
 		if varList.NextPage == 0 {
@@ -378,6 +407,19 @@ func listVariables(client *tfe.Client, workspace WorkspaceLite) (WorkspaceVars,
 	return result, nil
 }
 
+// listWorkspaceVariables looks up the name of the workspace and lists its variables
+func listWorkspaceVariables(client *tfe.Client, organization string, workspaceID string) (WorkspaceVars, error) {
+	workspaceName, err := getWorkspaceNameByID(client, organization, workspaceID)
+	if err != nil {
+		return WorkspaceVars{}, err
+	}
+
+	return listVariables(client, WorkspaceLite{
+		WorkspaceID:   workspaceID,
+		WorkspaceName: workspaceName,
+	})
+}
+
 func readVariable(client *tfe.Client, workspace WorkspaceLite, variableID string) (WorkspaceVar, error) {
 	result := WorkspaceVar{}
 
@@ -410,7 +452,11 @@ func createVariable(client *tfe.Client, workspaceID string, key *string, value *
 	}
 
 	v, err := client.Variables.Create(context.Background(), workspaceID, options)
-	check(err)
+	if err != nil {
+		return result, err
+	}
+
+	recordVariableHistory("create", workspaceID, nil, v)
 
 	result = Variable{
 		ID:          v.ID,
@@ -425,19 +471,28 @@ func createVariable(client *tfe.Client, workspaceID string, key *string, value *
 	return result, nil
 }
 
-func updateVariable(client *tfe.Client, workspaceID string, variableID string, key *string, value *string, description *string, hcl *bool, sensitive *bool) (Variable, error) {
+func updateVariable(client *tfe.Client, workspaceID string, variableID string, key *string, value *string, description *string, category *tfe.CategoryType, hcl *bool, sensitive *bool) (Variable, error) {
 	var result Variable
 
 	options := tfe.VariableUpdateOptions{
 		Key:         key,
 		Value:       value,
 		Description: description,
+		Category:    category,
 		HCL:         hcl,
 		Sensitive:   sensitive,
 	}
 
+	prior := readPriorVariable(client, workspaceID, variableID)
+
 	v, err := client.Variables.Update(context.Background(), workspaceID, variableID, options)
-	check(err)
+	if err != nil {
+		return result, err
+	}
+
+	if prior != nil {
+		recordVariableHistory("update", workspaceID, prior, nil)
+	}
 
 	result = Variable{
 		ID:          v.ID,
@@ -453,10 +508,30 @@ func updateVariable(client *tfe.Client, workspaceID string, variableID string, k
 }
 
 func deleteVariable(client *tfe.Client, workspaceID string, variableID string) error {
+	prior := readPriorVariable(client, workspaceID, variableID)
 
 	err := client.Variables.Delete(context.Background(), workspaceID, variableID)
+	if err != nil {
+		return err
+	}
+
+	if prior != nil {
+		recordVariableHistory("delete", workspaceID, prior, nil)
+	}
+
+	return nil
+}
+
+// readPriorVariable reads a variable before it's changed to record its
+// history. Failing to read it doesn't block the change, which isn't recorded.
+func readPriorVariable(client *tfe.Client, workspaceID string, variableID string) *tfe.Variable {
+	prior, err := client.Variables.Read(context.Background(), workspaceID, variableID)
+	if err != nil {
+		log.Warnf("Unable to read variable %s, its history won't be recorded: %v", variableID, err)
+		return nil
+	}
 
-	return err
+	return prior
 }
 
 func readJsonFile(file string) []byte {
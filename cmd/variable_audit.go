@@ -0,0 +1,383 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+	"gopkg.in/yaml.v3"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Key globs of variables which are expected to hold credentials, matched case insensitively
+var defaultSensitiveKeys = []string{
+	"*SECRET*",
+	"*TOKEN*",
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*API_KEY*",
+	"*APIKEY*",
+	"*PRIVATE_KEY*",
+	"*ACCESS_KEY*",
+	"*CREDENTIAL*",
+}
+
+// Patterns of credentials found in variable values
+var credentialValuePatterns = map[string]*regexp.Regexp{
+	"AWS access key ID":   regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	"AWS secret key":      regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}[A-Za-z0-9/+=]{40}`),
+	"private key":         regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	"GitHub token":        regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36}\b`),
+	"Slack token":         regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	"TFE/HCP user token":  regexp.MustCompile(`\b[A-Za-z0-9]{14}\.atlasv1\.[A-Za-z0-9_-]{60,}`),
+	"Google API key":      regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	"Azure client secret": regexp.MustCompile(`\b[A-Za-z0-9_~.-]{3}8Q~[A-Za-z0-9_~.-]{31,34}\b`),
+}
+
+// Names of the built-in heuristics, which can be disabled in the rules file
+const (
+	auditSensitiveKey   = "sensitive_key"
+	auditCredential     = "credential_value"
+	auditEmptyValue     = "empty_value"
+	auditDuplicateValue = "duplicate_value"
+)
+
+// Severities of audit findings, blocking findings fail the audit
+var variableAuditSeverities = []string{"blocking", "warning", "info"}
+
+type VariableAuditRules struct {
+	Disable            []string            `yaml:"disable"`
+	SensitiveKeys      []string            `yaml:"sensitive_keys"`
+	DuplicateThreshold int                 `yaml:"duplicate_threshold"`
+	Rules              []VariableAuditRule `yaml:"rules"`
+}
+
+// VariableAuditRule selects variables by category and key globs and checks
+// the selected variables: their key has to match key_pattern, their value
+// must not match value_pattern and they have to be sensitive when required.
+type VariableAuditRule struct {
+	Name         string   `yaml:"name"`
+	Description  string   `yaml:"description"`
+	Severity     string   `yaml:"severity"`
+	Categories   []string `yaml:"categories"`
+	Keys         []string `yaml:"keys"`
+	KeyPattern   string   `yaml:"key_pattern"`
+	ValuePattern string   `yaml:"value_pattern"`
+	Sensitive    bool     `yaml:"sensitive"`
+
+	keyRegexp   *regexp.Regexp
+	valueRegexp *regexp.Regexp
+}
+
+type VariableFinding struct {
+	Rule       string           `json:"rule"`
+	Severity   string           `json:"severity"`
+	Key        string           `json:"key"`
+	Category   tfe.CategoryType `json:"category"`
+	VariableID string           `json:"variable_id"`
+	Message    string           `json:"message"`
+}
+
+type VariableAudit struct {
+	Source     string            `json:"source"`
+	SourceID   string            `json:"source_id"`
+	SourceName string            `json:"source_name"`
+	Findings   []VariableFinding `json:"findings"`
+}
+
+var variableAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit the variables of a TFE Organization",
+	Long: `Audit the variables of every workspace and variable set of a TFE Organization.
+Built-in heuristics report credentials stored in non-sensitive variables, empty values and
+values duplicated across workspaces that could be a variable set.
+Additional rules, such as naming conventions, can be defined in a YAML file.
+Variable sets are only audited together with the whole organization, not with --filter.
+Only workspaces and variable sets with findings are reported.
+The command fails after reporting the findings when any of them is blocking.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		filter, _ := cmd.Flags().GetString("filter")
+		rulesFile, _ := cmd.Flags().GetString("rules")
+
+		rules, err := readVariableAuditRules(rulesFile)
+		check(err)
+
+		audits, err := auditOrganizationVariables(client, organization, filter, rules)
+		check(err)
+
+		results := []VariableAudit{}
+		findings := 0
+		blocking := 0
+		for _, a := range audits {
+			if len(a.Findings) == 0 {
+				continue
+			}
+			findings += len(a.Findings)
+			for _, f := range a.Findings {
+				if f.Severity == "blocking" {
+					blocking++
+				}
+			}
+			results = append(results, a)
+		}
+
+		if findings > 0 {
+			log.Warnf("%d findings in %d of %d workspaces and variable sets", findings, len(results), len(audits))
+		}
+
+		auditJson, _ := json.MarshalIndent(results, "", "  ")
+		outputData(cmd, auditJson)
+
+		if blocking > 0 {
+			log.Fatalf("%d blocking findings", blocking)
+		}
+	},
+}
+
+func init() {
+	variableCmd.AddCommand(variableAuditCmd)
+
+	variableAuditCmd.Flags().String("filter", "", "Only audit workspaces matching filter, variable sets are skipped")
+	variableAuditCmd.Flags().String("rules", "", "YAML file containing additional audit rules")
+}
+
+func readVariableAuditRules(file string) (VariableAuditRules, error) {
+	var result VariableAuditRules
+
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return result, err
+		}
+
+		err = yaml.Unmarshal(content, &result)
+		if err != nil {
+			return result, fmt.Errorf("unable to parse rules file %s: %v", file, err)
+		}
+	}
+
+	if result.DuplicateThreshold == 0 {
+		result.DuplicateThreshold = 3
+	}
+
+	for i, rule := range result.Rules {
+		if rule.Severity == "" {
+			result.Rules[i].Severity = "warning"
+		}
+		if !containsString(variableAuditSeverities, result.Rules[i].Severity) {
+			return result, fmt.Errorf("rule %s has unsupported severity %s, use one of %s", rule.Name, rule.Severity, strings.Join(variableAuditSeverities, ", "))
+		}
+
+		if rule.KeyPattern == "" && rule.ValuePattern == "" && !rule.Sensitive {
+			return result, fmt.Errorf("rule %s has to define at least one of key_pattern, value_pattern or sensitive", rule.Name)
+		}
+
+		var err error
+		if rule.KeyPattern != "" {
+			if result.Rules[i].keyRegexp, err = regexp.Compile(rule.KeyPattern); err != nil {
+				return result, fmt.Errorf("rule %s has an invalid key_pattern: %v", rule.Name, err)
+			}
+		}
+		if rule.ValuePattern != "" {
+			if result.Rules[i].valueRegexp, err = regexp.Compile(rule.ValuePattern); err != nil {
+				return result, fmt.Errorf("rule %s has an invalid value_pattern: %v", rule.Name, err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func auditOrganizationVariables(client *tfe.Client, organization string, filter string, rules VariableAuditRules) ([]VariableAudit, error) {
+	var results []VariableAudit
+
+	workspaces, err := listWorkspaces(client, organization, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Variables of each workspace, in the order of results
+	var workspaceVariables [][]Variable
+
+	for _, workspace := range workspaces {
+		log.Debugf("Auditing variables of workspace: %s - %s", workspace.Name, workspace.ID)
+
+		w, err := listVariables(client, WorkspaceLite{
+			WorkspaceID:   workspace.ID,
+			WorkspaceName: workspace.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		workspaceVariables = append(workspaceVariables, w.Variables)
+
+		results = append(results, VariableAudit{
+			Source:     "workspace",
+			SourceID:   workspace.ID,
+			SourceName: workspace.Name,
+			Findings:   auditVariables(w.Variables, rules),
+		})
+	}
+
+	if !containsString(rules.Disable, auditDuplicateValue) {
+		auditDuplicateValues(results, workspaceVariables, rules.DuplicateThreshold)
+	}
+
+	// Variable sets are only audited when auditing the whole organization
+	if filter == "" {
+		variableSets, err := listVariableSets(client, organization, "")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, vs := range variableSets {
+			log.Debugf("Auditing variables of variable set: %s - %s", vs.Name, vs.ID)
+
+			variables, err := listVariableSetVariables(client, vs.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			results = append(results, VariableAudit{
+				Source:     "varset",
+				SourceID:   vs.ID,
+				SourceName: vs.Name,
+				Findings:   auditVariables(variables, rules),
+			})
+		}
+	}
+
+	for _, r := range results {
+		sort.SliceStable(r.Findings, func(i, j int) bool {
+			return r.Findings[i].Key < r.Findings[j].Key
+		})
+	}
+
+	return results, nil
+}
+
+// auditDuplicateValues adds a finding to every workspace sharing the key,
+// category and value of a non-sensitive variable with at least threshold
+// workspaces. variables holds the variables of the workspace of each audit.
+func auditDuplicateValues(audits []VariableAudit, variables [][]Variable, threshold int) {
+	// Workspaces by key, category and value of their non-sensitive variables
+	values := map[string][]int{}
+
+	for i, workspaceVariables := range variables {
+		for _, v := range workspaceVariables {
+			if !v.Sensitive && v.Value != "" {
+				id := strings.Join([]string{string(v.Category), v.Key, v.Value}, "\x00")
+				values[id] = append(values[id], i)
+			}
+		}
+	}
+
+	for id, indexes := range values {
+		if len(indexes) < threshold {
+			continue
+		}
+
+		parts := strings.SplitN(id, "\x00", 3)
+		for _, i := range indexes {
+			audits[i].Findings = append(audits[i].Findings, VariableFinding{
+				Rule:     auditDuplicateValue,
+				Severity: "info",
+				Key:      parts[1],
+				Category: tfe.CategoryType(parts[0]),
+				Message:  fmt.Sprintf("same value in %d workspaces, consider moving it to a variable set", len(indexes)),
+			})
+		}
+	}
+}
+
+// auditVariables applies the built-in heuristics and the rules to the variables
+func auditVariables(variables []Variable, rules VariableAuditRules) []VariableFinding {
+	results := []VariableFinding{}
+
+	sensitiveKeys := append([]string{}, defaultSensitiveKeys...)
+	for _, k := range rules.SensitiveKeys {
+		sensitiveKeys = append(sensitiveKeys, strings.ToUpper(k))
+	}
+
+	for _, v := range variables {
+		finding := VariableFinding{
+			Key:        v.Key,
+			Category:   v.Category,
+			VariableID: v.ID,
+		}
+
+		if !v.Sensitive && !containsString(rules.Disable, auditSensitiveKey) && matchesAnyGlob(sensitiveKeys, strings.ToUpper(v.Key)) {
+			finding.Rule = auditSensitiveKey
+			finding.Severity = "warning"
+			finding.Message = "key suggests a credential but the variable is not sensitive"
+			results = append(results, finding)
+		}
+
+		if !v.Sensitive && !containsString(rules.Disable, auditCredential) {
+			for _, name := range sortedCredentialPatterns() {
+				if credentialValuePatterns[name].MatchString(v.Value) {
+					finding.Rule = auditCredential
+					finding.Severity = "blocking"
+					finding.Message = fmt.Sprintf("value looks like a credential (%s) but the variable is not sensitive", name)
+					results = append(results, finding)
+					break
+				}
+			}
+		}
+
+		if !v.Sensitive && v.Value == "" && !containsString(rules.Disable, auditEmptyValue) {
+			finding.Rule = auditEmptyValue
+			finding.Severity = "info"
+			finding.Message = "value is empty"
+			results = append(results, finding)
+		}
+
+		for _, rule := range rules.Rules {
+			if len(rule.Categories) > 0 && !containsString(rule.Categories, string(v.Category)) {
+				continue
+			}
+			if len(rule.Keys) > 0 && !matchesAnyGlob(rule.Keys, v.Key) {
+				continue
+			}
+
+			finding.Rule = rule.Name
+			finding.Severity = rule.Severity
+
+			if rule.keyRegexp != nil && !rule.keyRegexp.MatchString(v.Key) {
+				finding.Message = fmt.Sprintf("key doesn't match %s", rule.KeyPattern)
+				results = append(results, finding)
+			}
+			if rule.valueRegexp != nil && !v.Sensitive && rule.valueRegexp.MatchString(v.Value) {
+				finding.Message = fmt.Sprintf("value matches %s", rule.ValuePattern)
+				results = append(results, finding)
+			}
+			if rule.Sensitive && !v.Sensitive {
+				finding.Message = "variable is not sensitive"
+				results = append(results, finding)
+			}
+		}
+	}
+
+	return results
+}
+
+func sortedCredentialPatterns() []string {
+	var names []string
+	for name := range credentialValuePatterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
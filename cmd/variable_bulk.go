@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type WorkspaceVariableChange struct {
+	WorkspaceLite
+	VariableChange
+	Error string `json:"error"`
+}
+
+var variableSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Create or update a variable by key in TFE workspaces",
+	Long: `Create or update a variable by key and category in every matched TFE workspace.
+Workspaces are matched with --workspace-ids or --workspace-filter, which supports tags with a prefix of tags|.
+A failure in one workspace doesn't stop the others, the result of each workspace is reported.
+Use --dry-run to only show the changes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		key, _ := cmd.Flags().GetString("key")
+		value, _ := cmd.Flags().GetString("value")
+		description, _ := cmd.Flags().GetString("description")
+		categoryTypeStr, _ := cmd.Flags().GetString("type")
+		hcl, _ := cmd.Flags().GetBool("hcl")
+		sensitive, _ := cmd.Flags().GetBool("sensitive")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if key == "" {
+			log.Fatal("please provide the key of the variable to perform this operation!")
+		}
+
+		err = resolveVariableSource(&value, &sensitive)
+		check(err)
+
+		desired := Variable{
+			Key:       key,
+			Value:     value,
+			Category:  tfe.CategoryType(categoryTypeStr),
+			HCL:       hcl,
+			Sensitive: sensitive,
+		}
+
+		results := []WorkspaceVariableChange{}
+		for _, workspace := range getVariableWorkspaces(cmd, client, organization) {
+			log.Debugf("Setting variable %s in workspace: %s - %s", key, workspace.WorkspaceName, workspace.WorkspaceID)
+			results = append(results, setWorkspaceVariable(client, workspace, desired, description, dryRun))
+		}
+
+		outputWorkspaceVariableChanges(cmd, results)
+	},
+}
+
+var variableUnsetCmd = &cobra.Command{
+	Use:   "unset",
+	Short: "Delete a variable by key from TFE workspaces",
+	Long: `Delete a variable by key from every matched TFE workspace.
+Without --type the variable is deleted from both categories.
+Workspaces are matched with --workspace-ids or --workspace-filter, which supports tags with a prefix of tags|.
+A failure in one workspace doesn't stop the others, the result of each workspace is reported.
+Use --dry-run to only show the changes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		key, _ := cmd.Flags().GetString("key")
+		categoryTypeStr, _ := cmd.Flags().GetString("type")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if key == "" {
+			log.Fatal("please provide the key of the variable to perform this operation!")
+		}
+
+		results := []WorkspaceVariableChange{}
+		for _, workspace := range getVariableWorkspaces(cmd, client, organization) {
+			log.Debugf("Unsetting variable %s in workspace: %s - %s", key, workspace.WorkspaceName, workspace.WorkspaceID)
+			results = append(results, unsetWorkspaceVariable(client, workspace, key, tfe.CategoryType(categoryTypeStr), dryRun)...)
+		}
+
+		outputWorkspaceVariableChanges(cmd, results)
+	},
+}
+
+func init() {
+	variableCmd.AddCommand(variableSetCmd)
+	variableSetCmd.Flags().String("workspace-ids", "", "Comma separated list of workspaceIDs")
+	variableSetCmd.Flags().String("workspace-filter", "", "Search filter for workspace")
+	variableSetCmd.Flags().String("key", "", "Variable Name")
+	variableSetCmd.Flags().String("value", "", "Variable Value")
+	variableSetCmd.Flags().Bool("sensitive", false, "Set sensitive flag for variable")
+	variableSetCmd.Flags().Bool("hcl", false, "Set if variable has HCL syntax")
+	variableSetCmd.Flags().String("type", "env", "Variable type")
+	variableSetCmd.Flags().String("description", "Variable Created by tfectl", "Description for created variables")
+	variableSetCmd.Flags().Bool("dry-run", false, "Only show the changes, don't apply them")
+
+	variableCmd.AddCommand(variableUnsetCmd)
+	variableUnsetCmd.Flags().String("workspace-ids", "", "Comma separated list of workspaceIDs")
+	variableUnsetCmd.Flags().String("workspace-filter", "", "Search filter for workspace")
+	variableUnsetCmd.Flags().String("key", "", "Variable Name")
+	variableUnsetCmd.Flags().String("type", "", "Variable type, defaults to both types")
+	variableUnsetCmd.Flags().Bool("dry-run", false, "Only show the changes, don't apply them")
+}
+
+func setWorkspaceVariable(client *tfe.Client, workspace WorkspaceLite, desired Variable, description string, dryRun bool) WorkspaceVariableChange {
+	result := WorkspaceVariableChange{
+		WorkspaceLite: workspace,
+		VariableChange: VariableChange{
+			Key:      desired.Key,
+			Category: desired.Category,
+		},
+	}
+
+	existing, err := listVariables(client, workspace)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.VariableChange = planVariableChanges(existing.Variables, []Variable{desired})[0]
+
+	if !dryRun {
+		result.VariableID, err = applyVariableChange(client, workspace.WorkspaceID, result.VariableChange, description)
+		if err != nil {
+			result.Error = err.Error()
+		}
+	}
+
+	return result
+}
+
+func unsetWorkspaceVariable(client *tfe.Client, workspace WorkspaceLite, key string, category tfe.CategoryType, dryRun bool) []WorkspaceVariableChange {
+	var results []WorkspaceVariableChange
+
+	existing, err := listVariables(client, workspace)
+	if err != nil {
+		return []WorkspaceVariableChange{{
+			WorkspaceLite:  workspace,
+			VariableChange: VariableChange{Key: key, Category: category},
+			Error:          err.Error(),
+		}}
+	}
+
+	for _, v := range existing.Variables {
+		if v.Key != key || (category != "" && v.Category != category) {
+			continue
+		}
+
+		result := WorkspaceVariableChange{
+			WorkspaceLite: workspace,
+			VariableChange: VariableChange{
+				Key:        v.Key,
+				Category:   v.Category,
+				Action:     "delete",
+				VariableID: v.ID,
+				OldValue:   v.Value,
+				HCL:        v.HCL,
+				Sensitive:  v.Sensitive,
+			},
+		}
+
+		if !dryRun {
+			log.Debugf("Deleting variable %s from workspace: %s", v.ID, workspace.WorkspaceID)
+			if err := deleteVariable(client, workspace.WorkspaceID, v.ID); err != nil {
+				result.Error = err.Error()
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		results = append(results, WorkspaceVariableChange{
+			WorkspaceLite:  workspace,
+			VariableChange: VariableChange{Key: key, Category: category, Action: "absent"},
+		})
+	}
+
+	return results
+}
+
+func outputWorkspaceVariableChanges(cmd *cobra.Command, results []WorkspaceVariableChange) {
+	failed := 0
+	for i, r := range results {
+		results[i].VariableChange = maskVariableChanges([]VariableChange{r.VariableChange})[0]
+		if r.Error != "" {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		log.Warnf("%d of %d changes failed", failed, len(results))
+	}
+
+	resultsJson, _ := json.MarshalIndent(results, "", "  ")
+	outputData(cmd, resultsJson)
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Precedence of variable set scopes, from the most to the least specific
+var variableSetScopeOrder = map[string]int{
+	"workspace": 0,
+	"project":   1,
+	"global":    2,
+}
+
+type VariableDefinition struct {
+	VariableID string `json:"variable_id"`
+	Value      string `json:"value"`
+	HCL        bool   `json:"hcl"`
+	Sensitive  bool   `json:"sensitive"`
+	Source     string `json:"source"`
+	SourceID   string `json:"source_id"`
+	SourceName string `json:"source_name"`
+	Scope      string `json:"scope"`
+	Priority   bool   `json:"priority"`
+}
+
+type EffectiveVariable struct {
+	Key      string           `json:"key"`
+	Category tfe.CategoryType `json:"category"`
+	VariableDefinition
+	Shadowed []VariableDefinition `json:"shadowed"`
+}
+
+var variableEffectiveCmd = &cobra.Command{
+	Use:   "effective",
+	Short: "Show the effective variables of a TFE workspace",
+	Long: `Show the effective variables of a TFE workspace, merging workspace variables
+with every variable set that applies to the workspace.
+For each key and category the winning definition is shown along with the definitions it shadows.
+Precedence from highest to lowest: priority variable sets, workspace variables,
+then variable sets applied to the workspace, to its project and globally.
+Between variable sets of the same level, the set whose name sorts first wins.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		workspaceID, _ := cmd.Flags().GetString("workspace-id")
+
+		if workspaceID == "" {
+			log.Fatal("please provide workspace-id to perform this operation!")
+		}
+
+		effective, err := getEffectiveVariables(client, workspaceID)
+		check(err)
+
+		effectiveJson, _ := json.MarshalIndent(effective, "", "  ")
+		outputData(cmd, effectiveJson)
+	},
+}
+
+func init() {
+	variableCmd.AddCommand(variableEffectiveCmd)
+	variableEffectiveCmd.Flags().String("workspace-id", "", "workspaceID")
+}
+
+func getEffectiveVariables(client *tfe.Client, workspaceID string) ([]EffectiveVariable, error) {
+	workspace, err := client.Workspaces.ReadByID(context.Background(), workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceVars, err := listVariables(client, WorkspaceLite{
+		WorkspaceID:   workspace.ID,
+		WorkspaceName: workspace.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := map[string][]VariableDefinition{}
+	categories := map[string]tfe.CategoryType{}
+
+	addDefinition := func(key string, category tfe.CategoryType, d VariableDefinition) {
+		id := string(category) + "/" + key
+		definitions[id] = append(definitions[id], d)
+		categories[id] = category
+	}
+
+	for _, v := range workspaceVars.Variables {
+		addDefinition(v.Key, v.Category, VariableDefinition{
+			VariableID: v.ID,
+			Value:      v.Value,
+			HCL:        v.HCL,
+			Sensitive:  v.Sensitive,
+			Source:     "workspace",
+			SourceID:   workspace.ID,
+			SourceName: workspace.Name,
+			Scope:      "workspace",
+		})
+	}
+
+	variableSets, err := listVariableSetsForWorkspace(client, workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vs := range variableSets {
+		scope := variableSetScope(vs, workspace)
+		if scope == "" {
+			log.Warnf("Unable to determine how variable set %s applies to workspace %s, assuming global", vs.ID, workspace.ID)
+			scope = "global"
+		}
+
+		variables, err := listVariableSetVariables(client, vs.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range variables {
+			addDefinition(v.Key, v.Category, VariableDefinition{
+				VariableID: v.ID,
+				Value:      v.Value,
+				HCL:        v.HCL,
+				Sensitive:  v.Sensitive,
+				Source:     "varset",
+				SourceID:   vs.ID,
+				SourceName: vs.Name,
+				Scope:      scope,
+				Priority:   vs.Priority,
+			})
+		}
+	}
+
+	results := []EffectiveVariable{}
+	for id, defs := range definitions {
+		sortVariableDefinitions(defs)
+
+		results = append(results, EffectiveVariable{
+			Key:                strings.SplitN(id, "/", 2)[1],
+			Category:           categories[id],
+			VariableDefinition: defs[0],
+			Shadowed:           defs[1:],
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Category != results[j].Category {
+			return results[i].Category < results[j].Category
+		}
+		return results[i].Key < results[j].Key
+	})
+
+	return results, nil
+}
+
+func listVariableSetsForWorkspace(client *tfe.Client, workspaceID string) ([]*tfe.VariableSet, error) {
+	results := []*tfe.VariableSet{}
+	currentPage := 1
+
+	for {
+		log.Debugf("Processing page %d.\n", currentPage)
+		options := &tfe.VariableSetListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: currentPage,
+				PageSize:   50,
+			},
+			Include: strings.Join([]string{
+				string(tfe.VariableSetWorkspaces),
+				string(tfe.VariableSetProjects),
+			}, ","),
+		}
+
+		vs, err := client.VariableSets.ListForWorkspace(context.Background(), workspaceID, options)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, vs.Items...)
+
+		if vs.NextPage == 0 {
+			break
+		}
+
+		currentPage++
+	}
+
+	return results, nil
+}
+
+// sortVariableDefinitions orders definitions of the same key and category
+// from the winning one to the most shadowed one.
+func sortVariableDefinitions(defs []VariableDefinition) {
+	sort.SliceStable(defs, func(i, j int) bool {
+		ri, rj := variableDefinitionRank(defs[i]), variableDefinitionRank(defs[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return defs[i].SourceName < defs[j].SourceName
+	})
+}
+
+func variableDefinitionRank(d VariableDefinition) int {
+	if d.Source == "workspace" {
+		return len(variableSetScopeOrder)
+	}
+
+	rank := variableSetScopeOrder[d.Scope]
+	if !d.Priority {
+		rank += len(variableSetScopeOrder) + 1
+	}
+
+	return rank
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortVariableDefinitions(t *testing.T) {
+	workspace := VariableDefinition{Source: "workspace", SourceName: "ws", Scope: "workspace"}
+	varset := func(name string, scope string, priority bool) VariableDefinition {
+		return VariableDefinition{Source: "varset", SourceName: name, Scope: scope, Priority: priority}
+	}
+
+	tt := []struct {
+		name string
+		defs []VariableDefinition
+		want []string
+	}{
+		{
+			name: "workspace variable wins over variable sets",
+			defs: []VariableDefinition{varset("global", "global", false), varset("project", "project", false), varset("direct", "workspace", false), workspace},
+			want: []string{"ws", "direct", "project", "global"},
+		},
+		{
+			name: "priority variable sets win over workspace variables",
+			defs: []VariableDefinition{workspace, varset("prio", "global", true)},
+			want: []string{"prio", "ws"},
+		},
+		{
+			name: "more specific priority variable sets win",
+			defs: []VariableDefinition{varset("prio-global", "global", true), varset("prio-project", "project", true), varset("prio-direct", "workspace", true)},
+			want: []string{"prio-direct", "prio-project", "prio-global"},
+		},
+		{
+			name: "any priority variable set wins over non-priority ones",
+			defs: []VariableDefinition{varset("direct", "workspace", false), varset("prio-global", "global", true)},
+			want: []string{"prio-global", "direct"},
+		},
+		{
+			name: "same level is ordered by name",
+			defs: []VariableDefinition{varset("b", "project", false), varset("a", "project", false), varset("c", "project", false)},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "single definition",
+			defs: []VariableDefinition{workspace},
+			want: []string{"ws"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sortVariableDefinitions(tc.defs)
+
+			var names []string
+			for _, d := range tc.defs {
+				names = append(names, d.SourceName)
+			}
+
+			require.Equal(t, tc.want, names)
+		})
+	}
+}
+
+func TestVariableSetScope(t *testing.T) {
+	workspace := &tfe.Workspace{ID: "ws-1", Project: &tfe.Project{ID: "prj-1"}}
+
+	tt := []struct {
+		name string
+		vs   *tfe.VariableSet
+		want string
+	}{
+		{
+			name: "applied to the workspace",
+			vs:   &tfe.VariableSet{Workspaces: []*tfe.Workspace{{ID: "ws-2"}, {ID: "ws-1"}}, Projects: []*tfe.Project{{ID: "prj-1"}}},
+			want: "workspace",
+		},
+		{
+			name: "applied to the project",
+			vs:   &tfe.VariableSet{Workspaces: []*tfe.Workspace{{ID: "ws-2"}}, Projects: []*tfe.Project{{ID: "prj-1"}}},
+			want: "project",
+		},
+		{
+			name: "global",
+			vs:   &tfe.VariableSet{Global: true, Projects: []*tfe.Project{{ID: "prj-2"}}},
+			want: "global",
+		},
+		{
+			name: "unknown",
+			vs:   &tfe.VariableSet{Projects: []*tfe.Project{{ID: "prj-2"}}},
+			want: "",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, variableSetScope(tc.vs, workspace))
+		})
+	}
+}
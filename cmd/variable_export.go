@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Dotenv values matching this pattern are written without quotes
+var dotenvPlainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@,+=-]*$`)
+
+var dotenvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+var variableExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export TFE workspace variables to tfvars, env or JSON files",
+	Long: `Export TFE workspace variables to tfvars, env or JSON files.
+The tfvars and json (.tfvars.json) formats contain the terraform variables and the env format contains the environment variables.
+The from-file format contains all non-sensitive variables and can be used with variable create from-file.
+The values of sensitive variables can't be read: they're written as commented placeholders in
+tfvars and env files and are left out of json and from-file files.
+String values starting with a value source prefix such as cmd: are escaped with a backslash,
+so that variable import and variable create from-file don't read them from the source.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		workspaceID, _ := cmd.Flags().GetString("workspace-id")
+		format, _ := cmd.Flags().GetString("format")
+		out, _ := cmd.Flags().GetString("out")
+
+		if workspaceID == "" {
+			log.Fatal("please provide workspace-id to perform this operation!")
+		}
+
+		w, err := listWorkspaceVariables(client, organization, workspaceID)
+		check(err)
+
+		variables := w.Variables
+		sort.SliceStable(variables, func(i, j int) bool {
+			return variables[i].Key < variables[j].Key
+		})
+
+		data, err := exportVariables(variables, format)
+		check(err)
+
+		if out != "" {
+			err = os.WriteFile(out, data, 0600)
+			check(err)
+			log.Infof("Variables exported to %s", out)
+			return
+		}
+
+		cmd.Print(string(data))
+	},
+}
+
+func init() {
+	variableCmd.AddCommand(variableExportCmd)
+	variableExportCmd.Flags().String("workspace-id", "", "workspaceID")
+	variableExportCmd.Flags().String("format", "tfvars", "Format of the export (tfvars, env, json or from-file)")
+	variableExportCmd.Flags().String("out", "", "File to write the export to, defaults to stdout")
+}
+
+func exportVariables(variables []Variable, format string) ([]byte, error) {
+	switch format {
+	case "tfvars":
+		return exportTfvars(filterVariableCategory(variables, tfe.CategoryTerraform)), nil
+	case "env":
+		return exportDotenv(filterVariableCategory(variables, tfe.CategoryEnv)), nil
+	case "json":
+		return exportTfvarsJSON(filterVariableCategory(variables, tfe.CategoryTerraform))
+	case "from-file":
+		return exportFromFile(variables)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q, use one of tfvars, env, json or from-file", format)
+	}
+}
+
+func filterVariableCategory(variables []Variable, category tfe.CategoryType) []Variable {
+	var results []Variable
+
+	for _, v := range variables {
+		if v.Category == category {
+			results = append(results, v)
+		}
+	}
+
+	if skipped := len(variables) - len(results); skipped > 0 {
+		log.Infof("Skipping %d variables which are not %s variables", skipped, category)
+	}
+
+	return results
+}
+
+func exportTfvars(variables []Variable) []byte {
+	var b strings.Builder
+
+	for _, v := range variables {
+		switch {
+		case v.Sensitive:
+			fmt.Fprintf(&b, "# %s = %s\n", v.Key, sensitiveValue)
+		case v.HCL:
+			fmt.Fprintf(&b, "%s = %s\n", v.Key, v.Value)
+		default:
+			fmt.Fprintf(&b, "%s = %s\n", v.Key, quoteHCLString(escapeValueSource(v.Value)))
+		}
+	}
+
+	return []byte(b.String())
+}
+
+func exportDotenv(variables []Variable) []byte {
+	var b strings.Builder
+
+	for _, v := range variables {
+		v.Value = escapeValueSource(v.Value)
+
+		switch {
+		case v.Sensitive:
+			fmt.Fprintf(&b, "# %s=%s\n", v.Key, sensitiveValue)
+		case dotenvPlainValue.MatchString(v.Value):
+			fmt.Fprintf(&b, "%s=%s\n", v.Key, v.Value)
+		default:
+			fmt.Fprintf(&b, "%s=%s\n", v.Key, quoteDotenv(v.Value))
+		}
+	}
+
+	return []byte(b.String())
+}
+
+func exportTfvarsJSON(variables []Variable) ([]byte, error) {
+	values := map[string]json.RawMessage{}
+
+	for _, v := range variables {
+		switch {
+		case v.Sensitive:
+			log.Warnf("Skipping %s: sensitive values can't be exported", v.Key)
+		case v.HCL:
+			// Only HCL values which are also valid JSON can be exported
+			if !json.Valid([]byte(v.Value)) {
+				log.Warnf("Skipping %s: the HCL value can't be converted to JSON", v.Key)
+				continue
+			}
+			values[v.Key] = json.RawMessage(v.Value)
+		default:
+			value, _ := json.Marshal(escapeValueSource(v.Value))
+			values[v.Key] = value
+		}
+	}
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+func exportFromFile(variables []Variable) ([]byte, error) {
+	result := Variables{Variables: []Variable{}}
+
+	for _, v := range variables {
+		if v.Sensitive {
+			log.Warnf("Skipping %s: sensitive values can't be exported", v.Key)
+			continue
+		}
+		v.ID = ""
+		v.Value = escapeValueSource(v.Value)
+		result.Variables = append(result.Variables, v)
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// quoteHCLString quotes a string for HCL, escaping template sequences.
+// Control characters without a short escape are written as \uXXXX.
+func quoteHCLString(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&b, `\u%04x`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// quoteDotenv double quotes a dotenv value with the escapes read by unquoteDotenv
+func quoteDotenv(s string) string {
+	return `"` + dotenvEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteHCLString(t *testing.T) {
+	tt := []struct {
+		value string
+		want  string
+	}{
+		{"plain", `"plain"`},
+		{"a\"b\\c", `"a\"b\\c"`},
+		{"line\nnext\ttab\r", `"line\nnext\ttab\r"`},
+		{"bell\a nul\x00", `"bell\u0007 nul\u0000"`},
+		{"é 😀", `"é 😀"`},
+		{"${var} %{if}", `"$${var} %%{if}"`},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.want, quoteHCLString(tc.value))
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	values := []string{"plain", "a\"b\\c", "line\nnext\ttab", "bell\a \\x41", "${var} %{if}", "é 😀", "# not a comment", "cmd:x"}
+
+	var variables []Variable
+	for i, v := range values {
+		variables = append(variables, Variable{Key: string(rune('a' + i)), Value: v})
+	}
+
+	// Values are resolved like variable import does
+	resolve := func(variables []Variable) []Variable {
+		for i := range variables {
+			require.NoError(t, resolveVariableSource(&variables[i].Value, &variables[i].Sensitive))
+		}
+		return variables
+	}
+
+	got, err := parseTfvars(exportTfvars(variables))
+	require.NoError(t, err)
+	require.Equal(t, variables, resolve(got))
+
+	// Dotenv files can't hold control characters other than \n, \r and \t
+	dotenvVariables := append(variables[:3:3], variables[7])
+	got, err = parseDotenv(exportDotenv(dotenvVariables))
+	require.NoError(t, err)
+	require.Equal(t, dotenvVariables, resolve(got))
+
+	data, err := exportTfvarsJSON(variables)
+	require.NoError(t, err)
+	got, err = parseTfvarsJSON(data)
+	require.NoError(t, err)
+	require.ElementsMatch(t, variables, resolve(got))
+}
+
+func TestExportFromFile(t *testing.T) {
+	variables := []Variable{
+		{ID: "var-1", Key: "plain", Value: "a", Category: tfe.CategoryTerraform},
+		{ID: "var-2", Key: "secret", Category: tfe.CategoryEnv, Sensitive: true},
+		{ID: "var-3", Key: "command", Value: "cmd:rm -rf /", Category: tfe.CategoryEnv},
+		{ID: "var-4", Key: "escaped", Value: `\env:HOME`, Category: tfe.CategoryEnv},
+	}
+
+	data, err := exportFromFile(variables)
+	require.NoError(t, err)
+
+	var result Variables
+	require.NoError(t, json.Unmarshal(data, &result))
+
+	require.Equal(t, []Variable{
+		{Key: "plain", Value: "a", Category: tfe.CategoryTerraform},
+		{Key: "command", Value: `\cmd:rm -rf /`, Category: tfe.CategoryEnv},
+		{Key: "escaped", Value: `\\env:HOME`, Category: tfe.CategoryEnv},
+	}, result.Variables)
+
+	// Re-importing the file restores the literal values
+	want := []string{"a", "cmd:rm -rf /", `\env:HOME`}
+	for i, v := range result.Variables {
+		sensitive := false
+		require.NoError(t, resolveVariableSource(&v.Value, &sensitive))
+		require.False(t, sensitive)
+		require.Equal(t, want[i], v.Value)
+	}
+}
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Layouts accepted by variable rollback --to, times without a zone are local times
+var variableHistoryTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// VariableRollbackChange is the result of restoring a variable
+type VariableRollbackChange struct {
+	VariableChange
+	Error string `json:"error"`
+}
+
+// VariableHistoryEntry records the state of a workspace variable before a
+// tfectl mutation. Existed is false when the mutation created the variable.
+// The values of sensitive variables are never recorded.
+type VariableHistoryEntry struct {
+	Timestamp   time.Time        `json:"timestamp"`
+	Operation   string           `json:"operation"`
+	WorkspaceID string           `json:"workspace_id"`
+	VariableID  string           `json:"variable_id"`
+	Key         string           `json:"key"`
+	Category    tfe.CategoryType `json:"category"`
+	Existed     bool             `json:"existed"`
+	Value       string           `json:"value"`
+	Description string           `json:"description"`
+	HCL         bool             `json:"hcl"`
+	Sensitive   bool             `json:"sensitive"`
+}
+
+var variableHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the local history of TFE workspace variables",
+	Long: `Show the local history of TFE workspace variables.
+Every tfectl command creating, updating or deleting workspace variables records the prior
+state of the variable in a local history file, TFECTL_VARIABLE_HISTORY or
+variable_history.jsonl in the tfectl user configuration directory.
+Changes made outside of tfectl aren't recorded and the values of sensitive variables are never recorded.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		workspaceID, _ := cmd.Flags().GetString("workspace-id")
+		key, _ := cmd.Flags().GetString("key")
+
+		if workspaceID == "" {
+			log.Fatal("please provide workspace-id to perform this operation!")
+		}
+
+		entries, err := readVariableHistory(workspaceID, key)
+		check(err)
+
+		historyJson, _ := json.MarshalIndent(entries, "", "  ")
+		outputData(cmd, historyJson)
+	},
+}
+
+var variableRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Restore TFE workspace variables from the local history",
+	Long: `Restore TFE workspace variables to their state at the given time using the local history.
+Variables are matched on key and category. Variables created after that time are deleted,
+and updated or deleted variables get their prior value and description back.
+Sensitive variables, and variables made sensitive since, can't be restored.
+--to takes a timestamp of variable history, such as 2025-01-31T10:00:00Z or 2025-01-31,
+times without a zone are in the local time zone.
+A failure on one variable doesn't stop the others, the result of each variable is reported.
+Use --dry-run to only show the changes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		workspaceID, _ := cmd.Flags().GetString("workspace-id")
+		key, _ := cmd.Flags().GetString("key")
+		to, _ := cmd.Flags().GetString("to")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if workspaceID == "" || to == "" {
+			log.Fatal("please provide workspace-id and to to perform this operation!")
+		}
+
+		toTime, err := parseVariableHistoryTime(to)
+		check(err)
+
+		entries, err := readVariableHistory(workspaceID, key)
+		check(err)
+
+		existing, err := listWorkspaceVariables(client, organization, workspaceID)
+		check(err)
+
+		changes := planVariableRollback(existing.Variables, entries, toTime)
+
+		results := []VariableRollbackChange{}
+		failed := 0
+
+		for _, c := range maskVariableChanges(changes) {
+			results = append(results, VariableRollbackChange{VariableChange: c})
+		}
+
+		if !dryRun {
+			for i, c := range changes {
+				id, err := applyVariableChangeWithDescription(client, workspaceID, c)
+				if err != nil {
+					results[i].Error = err.Error()
+					failed++
+					continue
+				}
+				results[i].VariableID = id
+			}
+		}
+
+		if failed > 0 {
+			log.Warnf("%d of %d variables could not be restored", failed, len(changes))
+		}
+
+		resultsJson, _ := json.MarshalIndent(results, "", "  ")
+		outputData(cmd, resultsJson)
+	},
+}
+
+func init() {
+	variableCmd.AddCommand(variableHistoryCmd)
+	variableHistoryCmd.Flags().String("workspace-id", "", "workspaceID")
+	variableHistoryCmd.Flags().String("key", "", "Only show the history of this key")
+
+	variableCmd.AddCommand(variableRollbackCmd)
+	variableRollbackCmd.Flags().String("workspace-id", "", "workspaceID")
+	variableRollbackCmd.Flags().String("key", "", "Only restore this key")
+	variableRollbackCmd.Flags().String("to", "", "Time to restore the variables to, in the local time zone unless given")
+	variableRollbackCmd.Flags().Bool("dry-run", false, "Only show the changes, don't apply them")
+}
+
+func variableHistoryFile() (string, error) {
+	if file := os.Getenv("TFECTL_VARIABLE_HISTORY"); file != "" {
+		return file, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "tfectl", "variable_history.jsonl"), nil
+}
+
+// recordVariableHistory appends the prior state of a variable to the history
+// file. Failing to record the history doesn't fail the mutation.
+func recordVariableHistory(operation string, workspaceID string, prior *tfe.Variable, created *tfe.Variable) {
+	entry := VariableHistoryEntry{
+		Timestamp:   time.Now().UTC(),
+		Operation:   operation,
+		WorkspaceID: workspaceID,
+	}
+
+	if prior != nil {
+		entry.VariableID = prior.ID
+		entry.Key = prior.Key
+		entry.Category = prior.Category
+		entry.Existed = true
+		entry.Description = prior.Description
+		entry.HCL = prior.HCL
+		entry.Sensitive = prior.Sensitive
+		if !prior.Sensitive {
+			entry.Value = prior.Value
+		}
+	} else if created != nil {
+		entry.VariableID = created.ID
+		entry.Key = created.Key
+		entry.Category = created.Category
+	}
+
+	if err := appendVariableHistory(entry); err != nil {
+		log.Warnf("Unable to record the history of variable %s: %v", entry.Key, err)
+	}
+}
+
+func appendVariableHistory(entry VariableHistoryEntry) error {
+	file, err := variableHistoryFile()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(file), 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(line, '\n'))
+
+	return err
+}
+
+// readVariableHistory returns the history of the workspace, and of the key
+// unless it's empty, ordered from the oldest to the newest entry
+func readVariableHistory(workspaceID string, key string) ([]VariableHistoryEntry, error) {
+	results := []VariableHistoryEntry{}
+
+	file, err := variableHistoryFile()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		var entry VariableHistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Warnf("Skipping line %d of %s: %v", line, file, err)
+			continue
+		}
+
+		if entry.WorkspaceID != workspaceID || (key != "" && entry.Key != key) {
+			continue
+		}
+
+		results = append(results, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.Before(results[j].Timestamp)
+	})
+
+	return results, nil
+}
+
+func parseVariableHistoryTime(value string) (time.Time, error) {
+	for _, layout := range variableHistoryTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse time %s, use a timestamp such as 2025-01-31T10:00:00Z or 2025-01-31", value)
+}
+
+// planVariableRollback returns the changes restoring the variables to their
+// state at the given time. The state of a variable at that time is the prior
+// state recorded by the first mutation made at or after it.
+func planVariableRollback(existing []Variable, entries []VariableHistoryEntry, to time.Time) []VariableChange {
+	results := []VariableChange{}
+	seen := map[string]bool{}
+
+	for _, entry := range entries {
+		id := string(entry.Category) + "/" + entry.Key
+		if entry.Timestamp.Before(to) || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		current, exists := findVariable(existing, entry.Key, entry.Category)
+
+		switch {
+		case !entry.Existed:
+			if !exists {
+				continue
+			}
+			results = append(results, VariableChange{
+				Key:        current.Key,
+				Category:   current.Category,
+				Action:     "delete",
+				VariableID: current.ID,
+				OldValue:   current.Value,
+				HCL:        current.HCL,
+				Sensitive:  current.Sensitive,
+			})
+		case entry.Sensitive:
+			log.Warnf("Skipping %s: the value of sensitive variables isn't recorded and can't be restored", entry.Key)
+		case exists && current.Sensitive:
+			log.Warnf("Skipping %s: the variable was made sensitive since and can't be made non-sensitive again", entry.Key)
+		default:
+			for _, c := range planVariableChanges(existing, []Variable{{
+				Key:      entry.Key,
+				Value:    entry.Value,
+				Category: entry.Category,
+				HCL:      entry.HCL,
+			}}) {
+				c.Description = entry.Description
+				if c.Action == "unchanged" && current.Description != entry.Description {
+					c.Action = "update"
+				}
+				results = append(results, c)
+			}
+		}
+	}
+
+	return results
+}
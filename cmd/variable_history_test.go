@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVariableHistoryTime(t *testing.T) {
+	// Times without a zone are local times
+	local := time.Local
+	time.Local = time.FixedZone("AEST", 10*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	tt := []struct {
+		value string
+		want  time.Time
+		err   bool
+	}{
+		{value: "2025-01-31T10:02:11.532081Z", want: time.Date(2025, 1, 31, 10, 2, 11, 532081000, time.UTC)},
+		{value: "2025-01-31T12:00:00+02:00", want: time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)},
+		{value: "2025-01-31T10:00:00", want: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-01-31 10:00:00", want: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-01-31", want: time.Date(2025, 1, 30, 14, 0, 0, 0, time.UTC)},
+		{value: "31/01/2025", err: true},
+		{value: "", err: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := parseVariableHistoryTime(tc.value)
+
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, tc.want.Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestPlanVariableRollback(t *testing.T) {
+	to := time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)
+	before := to.Add(-time.Hour)
+	after := to.Add(time.Hour)
+
+	tt := []struct {
+		name     string
+		existing []Variable
+		entries  []VariableHistoryEntry
+		want     []VariableChange
+	}{
+		{
+			name:     "created since is deleted",
+			existing: []Variable{{ID: "var-1", Key: "a", Value: "x", Category: tfe.CategoryTerraform}},
+			entries: []VariableHistoryEntry{
+				{Timestamp: after, Operation: "create", VariableID: "var-1", Key: "a", Category: tfe.CategoryTerraform},
+			},
+			want: []VariableChange{
+				{Key: "a", Category: tfe.CategoryTerraform, Action: "delete", VariableID: "var-1", OldValue: "x"},
+			},
+		},
+		{
+			name: "created since and already deleted",
+			entries: []VariableHistoryEntry{
+				{Timestamp: after, Operation: "create", VariableID: "var-1", Key: "a", Category: tfe.CategoryTerraform},
+			},
+			want: []VariableChange{},
+		},
+		{
+			name:     "updated since gets its prior value",
+			existing: []Variable{{ID: "var-1", Key: "a", Value: "new", Description: "d", Category: tfe.CategoryTerraform}},
+			entries: []VariableHistoryEntry{
+				{Timestamp: after, Operation: "update", VariableID: "var-1", Key: "a", Category: tfe.CategoryTerraform, Existed: true, Value: "old", Description: "d"},
+			},
+			want: []VariableChange{
+				{Key: "a", Category: tfe.CategoryTerraform, Action: "update", VariableID: "var-1", OldValue: "new", NewValue: "old", Description: "d"},
+			},
+		},
+		{
+			name:     "description changed since",
+			existing: []Variable{{ID: "var-1", Key: "a", Value: "x", Description: "new", Category: tfe.CategoryTerraform}},
+			entries: []VariableHistoryEntry{
+				{Timestamp: after, Operation: "update", VariableID: "var-1", Key: "a", Category: tfe.CategoryTerraform, Existed: true, Value: "x", Description: "old"},
+			},
+			want: []VariableChange{
+				{Key: "a", Category: tfe.CategoryTerraform, Action: "update", VariableID: "var-1", OldValue: "x", NewValue: "x", Description: "old"},
+			},
+		},
+		{
+			name:     "made sensitive since",
+			existing: []Variable{{ID: "var-1", Key: "a", Category: tfe.CategoryEnv, Sensitive: true}},
+			entries: []VariableHistoryEntry{
+				{Timestamp: after, Operation: "update", VariableID: "var-1", Key: "a", Category: tfe.CategoryEnv, Existed: true, Value: "x"},
+			},
+			want: []VariableChange{},
+		},
+		{
+			name: "deleted since is recreated with its description",
+			entries: []VariableHistoryEntry{
+				{Timestamp: after, Operation: "delete", VariableID: "var-1", Key: "a", Category: tfe.CategoryEnv, Existed: true, Value: "old", Description: "prior description", HCL: true},
+			},
+			want: []VariableChange{
+				{Key: "a", Category: tfe.CategoryEnv, Action: "create", NewValue: "old", HCL: true, Description: "prior description"},
+			},
+		},
+		{
+			name:     "first entry at or after the time wins",
+			existing: []Variable{{ID: "var-1", Key: "a", Value: "third", Category: tfe.CategoryTerraform}},
+			entries: []VariableHistoryEntry{
+				{Timestamp: before, Operation: "update", VariableID: "var-1", Key: "a", Category: tfe.CategoryTerraform, Existed: true, Value: "first"},
+				{Timestamp: to, Operation: "update", VariableID: "var-1", Key: "a", Category: tfe.CategoryTerraform, Existed: true, Value: "second"},
+				{Timestamp: after, Operation: "update", VariableID: "var-1", Key: "a", Category: tfe.CategoryTerraform, Existed: true, Value: "third"},
+			},
+			want: []VariableChange{
+				{Key: "a", Category: tfe.CategoryTerraform, Action: "update", VariableID: "var-1", OldValue: "third", NewValue: "second"},
+			},
+		},
+		{
+			name:     "unchanged since",
+			existing: []Variable{{ID: "var-1", Key: "a", Value: "x", Category: tfe.CategoryTerraform}},
+			entries: []VariableHistoryEntry{
+				{Timestamp: after, Operation: "update", VariableID: "var-1", Key: "a", Category: tfe.CategoryTerraform, Existed: true, Value: "x"},
+			},
+			want: []VariableChange{
+				{Key: "a", Category: tfe.CategoryTerraform, Action: "unchanged", VariableID: "var-1", OldValue: "x", NewValue: "x"},
+			},
+		},
+		{
+			name:     "categories are restored separately",
+			existing: []Variable{{ID: "var-1", Key: "a", Value: "new", Category: tfe.CategoryEnv}},
+			entries: []VariableHistoryEntry{
+				{Timestamp: after, Operation: "update", VariableID: "var-1", Key: "a", Category: tfe.CategoryEnv, Existed: true, Value: "old"},
+				{Timestamp: after, Operation: "delete", VariableID: "var-2", Key: "a", Category: tfe.CategoryTerraform, Existed: true, Value: "tf"},
+			},
+			want: []VariableChange{
+				{Key: "a", Category: tfe.CategoryEnv, Action: "update", VariableID: "var-1", OldValue: "new", NewValue: "old"},
+				{Key: "a", Category: tfe.CategoryTerraform, Action: "create", NewValue: "tf"},
+			},
+		},
+		{
+			name:     "sensitive variables are skipped",
+			existing: []Variable{{ID: "var-1", Key: "a", Category: tfe.CategoryEnv, Sensitive: true}},
+			entries: []VariableHistoryEntry{
+				{Timestamp: after, Operation: "update", VariableID: "var-1", Key: "a", Category: tfe.CategoryEnv, Existed: true, Sensitive: true},
+			},
+			want: []VariableChange{},
+		},
+		{
+			name:     "entries before the time are ignored",
+			existing: []Variable{{ID: "var-1", Key: "a", Value: "x", Category: tfe.CategoryTerraform}},
+			entries: []VariableHistoryEntry{
+				{Timestamp: before, Operation: "create", VariableID: "var-1", Key: "a", Category: tfe.CategoryTerraform},
+			},
+			want: []VariableChange{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, planVariableRollback(tc.existing, tc.entries, to))
+		})
+	}
+}
@@ -0,0 +1,771 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type VariableChange struct {
+	Key         string           `json:"key"`
+	Category    tfe.CategoryType `json:"category"`
+	Action      string           `json:"action"`
+	VariableID  string           `json:"variable_id"`
+	OldValue    string           `json:"old_value"`
+	NewValue    string           `json:"new_value"`
+	HCL         bool             `json:"hcl"`
+	Sensitive   bool             `json:"sensitive"`
+	Description string           `json:"description,omitempty"`
+}
+
+var variableImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import workspace variables from .tfvars, .tfvars.json or .env files",
+	Long: `Import workspace variables from .tfvars, .tfvars.json or .env files.
+Variables whose key already exists in the workspace are updated and new ones are created.
+Lists and objects are imported as HCL variables. Null values are skipped.
+The format is detected from the file name unless --format is given.
+String values can reference a secret source like env:NAME or file:/path, these
+variables are imported as sensitive.
+Use --dry-run to only show the changes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		workspaceID, _ := cmd.Flags().GetString("workspace-id")
+		file, _ := cmd.Flags().GetString("file")
+		format, _ := cmd.Flags().GetString("format")
+		category, _ := cmd.Flags().GetString("category")
+		description, _ := cmd.Flags().GetString("description")
+		sensitive, _ := cmd.Flags().GetBool("sensitive")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if workspaceID == "" || file == "" {
+			log.Fatal("please provide workspace-id and file to perform this operation!")
+		}
+
+		if format == "" {
+			format = detectVariableFileFormat(file)
+		}
+
+		if category == "" {
+			category = string(tfe.CategoryTerraform)
+			if format == "env" {
+				category = string(tfe.CategoryEnv)
+			}
+		}
+
+		desired, err := readVariableFile(file, format)
+		check(err)
+
+		for i := range desired {
+			desired[i].Category = tfe.CategoryType(category)
+			desired[i].Sensitive = sensitive
+
+			if !desired[i].HCL {
+				err = resolveVariableSource(&desired[i].Value, &desired[i].Sensitive)
+				check(err)
+			}
+		}
+
+		existing, err := listWorkspaceVariables(client, organization, workspaceID)
+		check(err)
+
+		changes := planVariableChanges(existing.Variables, desired)
+
+		if !dryRun {
+			for i, c := range changes {
+				changes[i].VariableID, err = applyVariableChange(client, workspaceID, c, description)
+				check(err)
+			}
+		}
+
+		changesJson, _ := json.MarshalIndent(maskVariableChanges(changes), "", "  ")
+		outputData(cmd, changesJson)
+	},
+}
+
+func init() {
+	variableCmd.AddCommand(variableImportCmd)
+	variableImportCmd.Flags().String("workspace-id", "", "workspaceID")
+	variableImportCmd.Flags().String("file", "", "File containing the variables")
+	variableImportCmd.Flags().String("format", "", "Format of the file (tfvars, json or env)")
+	variableImportCmd.Flags().String("category", "", "Variable type, defaults to env for .env files and terraform otherwise")
+	variableImportCmd.Flags().String("description", "Variable Imported by tfectl", "Description for created variables")
+	variableImportCmd.Flags().Bool("sensitive", false, "Set sensitive flag for imported variables")
+	variableImportCmd.Flags().Bool("dry-run", false, "Only show the changes, don't apply them")
+}
+
+// detectVariableFileFormat guesses the format of a variable file from its name
+func detectVariableFileFormat(file string) string {
+	name := filepath.Base(file)
+
+	switch {
+	case strings.HasSuffix(name, ".json"):
+		return "json"
+	case strings.HasPrefix(name, ".env") || strings.HasSuffix(name, ".env"):
+		return "env"
+	default:
+		return "tfvars"
+	}
+}
+
+func readVariableFile(file string, format string) ([]Variable, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "tfvars":
+		return parseTfvars(data)
+	case "json":
+		return parseTfvarsJSON(data)
+	case "env":
+		return parseDotenv(data)
+	default:
+		return nil, fmt.Errorf("unsupported variable file format %q, use one of tfvars, json or env", format)
+	}
+}
+
+// planVariableChanges compares the desired variables with the existing
+// variables of a workspace, matching them on key and category.
+func planVariableChanges(existing []Variable, desired []Variable) []VariableChange {
+	results := []VariableChange{}
+
+	for _, d := range desired {
+		change := VariableChange{
+			Key:       d.Key,
+			Category:  d.Category,
+			Action:    "create",
+			NewValue:  d.Value,
+			HCL:       d.HCL,
+			Sensitive: d.Sensitive,
+		}
+
+		for _, e := range existing {
+			if e.Key != d.Key || e.Category != d.Category {
+				continue
+			}
+
+			change.VariableID = e.ID
+			change.OldValue = e.Value
+			change.Sensitive = d.Sensitive || e.Sensitive
+
+			// The value of sensitive variables can't be read back, so they're always updated
+			if e.Sensitive || e.Value != d.Value || e.HCL != d.HCL || d.Sensitive {
+				change.Action = "update"
+			} else {
+				change.Action = "unchanged"
+			}
+			break
+		}
+
+		results = append(results, change)
+	}
+
+	return results
+}
+
+// applyVariableChange creates or updates the variable and returns its ID
+func applyVariableChange(client *tfe.Client, workspaceID string, c VariableChange, description string) (string, error) {
+	switch c.Action {
+	case "create":
+		log.Debugf("Creating variable %s in workspace: %s", c.Key, workspaceID)
+		v, err := createVariable(client, workspaceID, &c.Key, &c.NewValue, &description, &c.Category, &c.HCL, &c.Sensitive)
+		return v.ID, err
+	case "update":
+		log.Debugf("Updating variable %s in workspace: %s", c.Key, workspaceID)
+		v, err := updateVariable(client, workspaceID, c.VariableID, &c.Key, &c.NewValue, nil, nil, &c.HCL, &c.Sensitive)
+		return v.ID, err
+	}
+
+	return c.VariableID, nil
+}
+
+// applyVariableChangeWithDescription applies the change like
+// applyVariableChange, deleting variables too, and sets the description of
+// created and updated variables to the description of the change
+func applyVariableChangeWithDescription(client *tfe.Client, workspaceID string, c VariableChange) (string, error) {
+	switch c.Action {
+	case "delete":
+		log.Debugf("Deleting variable %s from workspace: %s", c.VariableID, workspaceID)
+		return c.VariableID, deleteVariable(client, workspaceID, c.VariableID)
+	case "update":
+		log.Debugf("Updating variable %s in workspace: %s", c.Key, workspaceID)
+		v, err := updateVariable(client, workspaceID, c.VariableID, &c.Key, &c.NewValue, &c.Description, nil, &c.HCL, &c.Sensitive)
+		if err != nil {
+			return c.VariableID, err
+		}
+		return v.ID, nil
+	}
+
+	return applyVariableChange(client, workspaceID, c, c.Description)
+}
+
+// maskVariableChanges hides the values of sensitive variables before output
+func maskVariableChanges(changes []VariableChange) []VariableChange {
+	results := []VariableChange{}
+
+	for _, c := range changes {
+		if c.Sensitive {
+			if c.Action != "create" {
+				c.OldValue = sensitiveValue
+			}
+			if c.Action != "delete" {
+				c.NewValue = sensitiveValue
+			}
+		}
+		results = append(results, c)
+	}
+
+	return results
+}
+
+func parseTfvarsJSON(data []byte) ([]Variable, error) {
+	var values map[string]json.RawMessage
+
+	err := json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var results []Variable
+	for _, k := range keys {
+		raw := bytes.TrimSpace(values[k])
+
+		switch raw[0] {
+		case 'n':
+			log.Warnf("Skipping %s: null values can't be set as workspace variables", k)
+		case '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, err
+			}
+			results = append(results, Variable{Key: k, Value: s})
+		case '[', '{':
+			// JSON lists and objects are valid HCL expressions
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, raw); err != nil {
+				return nil, err
+			}
+			results = append(results, Variable{Key: k, Value: compact.String(), HCL: true})
+		default:
+			results = append(results, Variable{Key: k, Value: string(raw)})
+		}
+	}
+
+	return results, nil
+}
+
+func parseDotenv(data []byte) ([]Variable, error) {
+	var results []Variable
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		idx := strings.Index(line, "=")
+		if idx < 1 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", i+1)
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			end := closingQuote(value)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value for %s", i+1, key)
+			}
+			value = unquoteDotenv(value[1:end])
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value for %s", i+1, key)
+			}
+			value = value[1 : end+1]
+		default:
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+
+		results = append(results, Variable{Key: key, Value: value})
+	}
+
+	return results, nil
+}
+
+// unquoteDotenv decodes the escape sequences of a double quoted dotenv value,
+// other backslashes are kept as they are
+func unquoteDotenv(s string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+
+	return replacer.Replace(s)
+}
+
+// closingQuote returns the index of the quote closing the double quoted
+// string at the start of s, or -1 when it isn't terminated.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		case '\n':
+			return -1
+		}
+	}
+
+	return -1
+}
+
+// Numbers allowed in variable files
+var hclNumber = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// tfvarsParser is a minimal parser for .tfvars files. It only supports
+// the subset of HCL that is valid in variable definition files: attributes
+// whose values are literals, lists, objects or heredocs.
+type tfvarsParser struct {
+	src string
+	pos int
+}
+
+func parseTfvars(data []byte) ([]Variable, error) {
+	p := &tfvarsParser{src: string(data)}
+	var results []Variable
+	seen := map[string]bool{}
+
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			break
+		}
+
+		line := p.line()
+		key := p.readIdentifier()
+		if key == "" || key[0] == '-' || ('0' <= key[0] && key[0] <= '9') {
+			return nil, fmt.Errorf("line %d: expected a variable name", line)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate variable %s", line, key)
+		}
+		seen[key] = true
+
+		p.skipSpace(false)
+		if p.eof() || p.src[p.pos] != '=' {
+			return nil, fmt.Errorf("line %d: expected '=' after %s", line, key)
+		}
+		p.pos++
+		p.skipSpace(false)
+
+		value, hcl, err := p.readValue()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %v", line, key, err)
+		}
+
+		p.skipSpace(false)
+		if !p.eof() && p.src[p.pos] != '\n' {
+			return nil, fmt.Errorf("line %d: unexpected %q after the value of %s", p.line(), p.src[p.pos], key)
+		}
+
+		if value == "null" && !hcl {
+			log.Warnf("Skipping %s: null values can't be set as workspace variables", key)
+			continue
+		}
+
+		results = append(results, Variable{Key: key, Value: value, HCL: hcl})
+	}
+
+	return results, nil
+}
+
+func (p *tfvarsParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tfvarsParser) line() int {
+	return strings.Count(p.src[:p.pos], "\n") + 1
+}
+
+func (p *tfvarsParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.src[p.pos:], prefix)
+}
+
+// skipSpace skips whitespace and comments, and newlines when asked to
+func (p *tfvarsParser) skipSpace(newlines bool) {
+	for !p.eof() {
+		switch {
+		case p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\r':
+			p.pos++
+		case p.src[p.pos] == '\n' && newlines:
+			p.pos++
+		case p.src[p.pos] == '#' || p.hasPrefix("//"):
+			p.skipLineComment()
+		case p.hasPrefix("/*"):
+			p.skipBlockComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tfvarsParser) skipLineComment() {
+	if idx := strings.Index(p.src[p.pos:], "\n"); idx >= 0 {
+		p.pos += idx
+	} else {
+		p.pos = len(p.src)
+	}
+}
+
+func (p *tfvarsParser) skipBlockComment() {
+	if idx := strings.Index(p.src[p.pos+2:], "*/"); idx >= 0 {
+		p.pos += idx + 4
+	} else {
+		p.pos = len(p.src)
+	}
+}
+
+func (p *tfvarsParser) readIdentifier() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.src[p.pos]
+		if c != '_' && c != '-' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			break
+		}
+		p.pos++
+	}
+
+	return p.src[start:p.pos]
+}
+
+// readValue returns the value of an attribute and whether it has to be
+// stored as HCL. Strings and heredocs are returned unquoted.
+func (p *tfvarsParser) readValue() (string, bool, error) {
+	if p.eof() {
+		return "", false, fmt.Errorf("missing value")
+	}
+
+	switch {
+	case p.src[p.pos] == '"':
+		value, err := p.readString()
+		return value, false, err
+	case p.hasPrefix("<<"):
+		value, err := p.readHeredoc()
+		return value, false, err
+	}
+
+	if c := p.src[p.pos]; c == '[' || c == '{' {
+		start := p.pos
+		if err := p.readCollection(); err != nil {
+			return "", false, err
+		}
+		return p.src[start:p.pos], true, nil
+	}
+
+	value, err := p.readLiteral()
+	return value, false, err
+}
+
+// readLiteral reads a number, bool or null
+func (p *tfvarsParser) readLiteral() (string, error) {
+	start := p.pos
+	for !p.eof() && strings.IndexByte("+-.0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	value := p.src[start:p.pos]
+
+	if value == "true" || value == "false" || value == "null" || hclNumber.MatchString(value) {
+		return value, nil
+	}
+
+	if value == "" {
+		if p.eof() {
+			return "", fmt.Errorf("missing value")
+		}
+		return "", fmt.Errorf("unexpected %q", p.src[p.pos])
+	}
+
+	return "", fmt.Errorf("unsupported value %s, only literals, lists and objects are allowed", value)
+}
+
+// readCollection checks the list or object starting at the current position
+// and moves past it. Its elements can only be literals, strings, heredocs and
+// nested lists and objects, object keys are names or strings.
+func (p *tfvarsParser) readCollection() error {
+	open := p.src[p.pos]
+	closing := byte(']')
+	if open == '{' {
+		closing = '}'
+	}
+	p.pos++
+
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return fmt.Errorf("unterminated list or object")
+		}
+
+		c := p.src[p.pos]
+		if c == closing {
+			p.pos++
+			return nil
+		}
+		if c == ']' || c == '}' || c == ')' {
+			return fmt.Errorf("expected %q but found %q", closing, c)
+		}
+
+		if open == '{' {
+			key, err := p.readObjectKey()
+			if err != nil {
+				return err
+			}
+			p.skipSpace(false)
+			if p.eof() || (p.src[p.pos] != '=' && p.src[p.pos] != ':') {
+				return fmt.Errorf("expected '=' after object key %s", key)
+			}
+			p.pos++
+			p.skipSpace(false)
+		}
+
+		if err := p.readElement(); err != nil {
+			return err
+		}
+
+		// Elements are separated by commas, and object attributes by newlines too
+		p.skipSpace(false)
+		separated := false
+		if !p.eof() && (p.src[p.pos] == ',' || (open == '{' && p.src[p.pos] == '\n')) {
+			p.pos++
+			separated = true
+		}
+
+		p.skipSpace(true)
+		if p.eof() {
+			return fmt.Errorf("unterminated list or object")
+		}
+		if c := p.src[p.pos]; !separated && c != closing {
+			if c == ']' || c == '}' || c == ')' {
+				return fmt.Errorf("expected %q but found %q", closing, c)
+			}
+			return fmt.Errorf("expected ',' or %q but found %q", closing, c)
+		}
+	}
+}
+
+// readElement checks the value of a list element or object attribute and
+// moves past it
+func (p *tfvarsParser) readElement() error {
+	if p.eof() {
+		return fmt.Errorf("unterminated list or object")
+	}
+
+	switch {
+	case p.src[p.pos] == '"':
+		_, err := p.readString()
+		return err
+	case p.hasPrefix("<<"):
+		_, err := p.readHeredoc()
+		return err
+	case p.src[p.pos] == '[' || p.src[p.pos] == '{':
+		return p.readCollection()
+	}
+
+	_, err := p.readLiteral()
+	return err
+}
+
+// readObjectKey reads a name or a quoted string used as object key
+func (p *tfvarsParser) readObjectKey() (string, error) {
+	if p.src[p.pos] == '"' {
+		return p.readString()
+	}
+
+	key := p.readIdentifier()
+	if key == "" || key[0] == '-' || ('0' <= key[0] && key[0] <= '9') {
+		return "", fmt.Errorf("expected an object key but found %q", p.src[p.pos])
+	}
+
+	return key, nil
+}
+
+// readString reads and unquotes the string at the current position
+func (p *tfvarsParser) readString() (string, error) {
+	end := closingQuote(p.src[p.pos:])
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string")
+	}
+
+	value, err := unquoteHCLString(p.src[p.pos : p.pos+end+1])
+	p.pos += end + 1
+
+	return value, err
+}
+
+func (p *tfvarsParser) readHeredoc() (string, error) {
+	p.pos += 2
+	indented := p.hasPrefix("-")
+	if indented {
+		p.pos++
+	}
+
+	marker := p.readIdentifier()
+	if marker == "" {
+		return "", fmt.Errorf("missing heredoc marker")
+	}
+
+	p.skipSpace(false)
+	if p.eof() || p.src[p.pos] != '\n' {
+		return "", fmt.Errorf("expected a newline after heredoc marker %s", marker)
+	}
+	p.pos++
+
+	var lines []string
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("unterminated heredoc, missing %s", marker)
+		}
+
+		end := strings.Index(p.src[p.pos:], "\n")
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		line := strings.TrimSuffix(p.src[p.pos:p.pos+end], "\r")
+		p.pos += end
+
+		if strings.TrimSpace(line) == marker {
+			break
+		}
+
+		lines = append(lines, line)
+		if !p.eof() {
+			p.pos++
+		}
+	}
+
+	if indented {
+		lines = trimCommonIndent(lines)
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	return decodeHCLTemplate(strings.Join(lines, "\n")+"\n", false)
+}
+
+func trimCommonIndent(lines []string) []string {
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	var results []string
+	for _, l := range lines {
+		if len(l) >= indent && indent > 0 {
+			l = l[indent:]
+		} else if strings.TrimSpace(l) == "" {
+			l = ""
+		}
+		results = append(results, l)
+	}
+
+	return results
+}
+
+// unquoteHCLString decodes a double quoted HCL string. Only the escape
+// sequences of HCL are accepted.
+func unquoteHCLString(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+
+	return decodeHCLTemplate(s[1:len(s)-1], true)
+}
+
+// decodeHCLTemplate decodes the literal content of a quoted string or heredoc.
+// Template sequences have to be escaped as $${ and %%{ as variable files
+// can't contain interpolations or directives. Heredocs have no backslash escapes.
+func decodeHCLTemplate(s string, escapes bool) (string, error) {
+	var sb strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case (c == '$' || c == '%') && strings.HasPrefix(s[i+1:], string(c)+"{"):
+			sb.WriteString(string(c) + "{")
+			i += 2
+		case (c == '$' || c == '%') && strings.HasPrefix(s[i+1:], "{"):
+			return "", fmt.Errorf("templates are not allowed in variable files, escape %c{ as %c%c{", c, c, c)
+		case c == '\\' && escapes:
+			if i+1 >= len(s) {
+				return "", fmt.Errorf("invalid escape sequence at the end of the string")
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"':
+				sb.WriteByte('"')
+			case '\\':
+				sb.WriteByte('\\')
+			case 'u', 'U':
+				size := 4
+				if s[i] == 'U' {
+					size = 8
+				}
+				if i+size >= len(s) {
+					return "", fmt.Errorf("invalid escape sequence \\%s", s[i:])
+				}
+				r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", fmt.Errorf("invalid escape sequence \\%s", s[i:i+1+size])
+				}
+				sb.WriteRune(rune(r))
+				i += size
+			default:
+				return "", fmt.Errorf("invalid escape sequence \\%c", s[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String(), nil
+}
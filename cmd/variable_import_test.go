@@ -0,0 +1,417 @@
+package cmd
+
+import (
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTfvars(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  []Variable
+		err   string
+	}{
+		{
+			name:  "literals",
+			input: "name = \"app\"\ncount = 3\nratio = 0.5\nenabled = true\n",
+			want: []Variable{
+				{Key: "name", Value: "app"},
+				{Key: "count", Value: "3"},
+				{Key: "ratio", Value: "0.5"},
+				{Key: "enabled", Value: "true"},
+			},
+		},
+		{
+			name:  "null values are skipped",
+			input: "a = null\nb = \"x\"\n",
+			want:  []Variable{{Key: "b", Value: "x"}},
+		},
+		{
+			name: "comments",
+			input: `# hash comment
+// slash comment
+/* block
+   comment */
+a = "x" # trailing hash
+b = "y" // trailing slash
+c = /* inline */ "z"
+d = "# not a comment"
+`,
+			want: []Variable{
+				{Key: "a", Value: "x"},
+				{Key: "b", Value: "y"},
+				{Key: "c", Value: "z"},
+				{Key: "d", Value: "# not a comment"},
+			},
+		},
+		{
+			name: "nested lists and objects",
+			input: `zones = ["a", "b"]
+tags = {
+  env  = "dev" # comment inside
+  team = "platform"
+  nested = {
+    list = [1, [2, 3], { x = "]" }]
+  }
+}
+`,
+			want: []Variable{
+				{Key: "zones", Value: `["a", "b"]`, HCL: true},
+				{Key: "tags", Value: "{\n  env  = \"dev\" # comment inside\n  team = \"platform\"\n  nested = {\n    list = [1, [2, 3], { x = \"]\" }]\n  }\n}", HCL: true},
+			},
+		},
+		{
+			name: "collection literals",
+			input: `a = [-1, 2.5e3, true, null, "x",]
+b = { "quoted key" = "v", colon: 1, list = [], obj = {} }
+c = [
+  1,
+  2,
+]
+`,
+			want: []Variable{
+				{Key: "a", Value: `[-1, 2.5e3, true, null, "x",]`, HCL: true},
+				{Key: "b", Value: `{ "quoted key" = "v", colon: 1, list = [], obj = {} }`, HCL: true},
+				{Key: "c", Value: "[\n  1,\n  2,\n]", HCL: true},
+			},
+		},
+		{
+			name:  "hcl escapes",
+			input: `a = "line\nnext\ttab \"quoted\" back\\slash \u00e9 \U0001F600"` + "\n",
+			want:  []Variable{{Key: "a", Value: "line\nnext\ttab \"quoted\" back\\slash é 😀"}},
+		},
+		{
+			name:  "escaped template sequences",
+			input: `a = "$${var} %%{if} $5 100%"` + "\n",
+			want:  []Variable{{Key: "a", Value: "${var} %{if} $5 100%"}},
+		},
+		{
+			name:  "heredoc",
+			input: "script = <<EOT\n#!/bin/sh\necho \"$${HOME}\" \\n\nEOT\nnext = 1\n",
+			want: []Variable{
+				{Key: "script", Value: "#!/bin/sh\necho \"${HOME}\" \\n\n"},
+				{Key: "next", Value: "1"},
+			},
+		},
+		{
+			name:  "indented heredoc",
+			input: "policy = <<-EOT\n    {\n      \"a\": 1\n    }\n    EOT\n",
+			want:  []Variable{{Key: "policy", Value: "{\n  \"a\": 1\n}\n"}},
+		},
+		{
+			name:  "empty heredoc",
+			input: "a = <<EOT\nEOT\n",
+			want:  []Variable{{Key: "a", Value: ""}},
+		},
+		{
+			name:  "crlf line endings",
+			input: "a = \"x\"\r\nb = 2\r\n",
+			want:  []Variable{{Key: "a", Value: "x"}, {Key: "b", Value: "2"}},
+		},
+		{
+			name:  "no trailing newline",
+			input: `a = "x"`,
+			want:  []Variable{{Key: "a", Value: "x"}},
+		},
+		{
+			name:  "go only escape",
+			input: `a = "\x41"`,
+			err:   `line 1: a: invalid escape sequence \x`,
+		},
+		{
+			name:  "bell escape",
+			input: `a = "\a"`,
+			err:   `line 1: a: invalid escape sequence \a`,
+		},
+		{
+			name:  "truncated unicode escape",
+			input: `a = "\u00"`,
+			err:   `line 1: a: invalid escape sequence \u00`,
+		},
+		{
+			name:  "interpolation",
+			input: `a = "${var.b}"`,
+			err:   "line 1: a: templates are not allowed in variable files, escape ${ as $${",
+		},
+		{
+			name:  "unterminated string",
+			input: "a = \"x\nb = 1\n",
+			err:   "line 1: a: unterminated string",
+		},
+		{
+			name:  "unterminated list",
+			input: "a = [1, 2\n",
+			err:   "line 1: a: unterminated list or object",
+		},
+		{
+			name:  "unbalanced bracket",
+			input: "a = 1]\n",
+			err:   "line 1: unexpected ']' after the value of a",
+		},
+		{
+			name:  "mismatched bracket",
+			input: "a = {\n  b = [1}\n}\n",
+			err:   "line 1: a: expected ']' but found '}'",
+		},
+		{
+			name:  "unterminated heredoc",
+			input: "a = <<EOT\nx\n",
+			err:   "line 1: a: unterminated heredoc, missing EOT",
+		},
+		{
+			name:  "missing equals",
+			input: "a \"x\"\n",
+			err:   "line 1: expected '=' after a",
+		},
+		{
+			name:  "missing value",
+			input: "a =",
+			err:   "line 1: a: missing value",
+		},
+		{
+			name:  "invalid name",
+			input: "1a = 1\n",
+			err:   "line 1: expected a variable name",
+		},
+		{
+			name:  "duplicate",
+			input: "a = 1\na = 2\n",
+			err:   "line 2: duplicate variable a",
+		},
+		{
+			name:  "two attributes on one line",
+			input: "a = 1 b = 2\n",
+			err:   "line 1: unexpected 'b' after the value of a",
+		},
+		{
+			name:  "references",
+			input: "a = var.b\n",
+			err:   "line 1: a: unsupported value var.b, only literals, lists and objects are allowed",
+		},
+		{
+			name:  "references in a list",
+			input: `a = [var.b, "c"]`,
+			err:   "line 1: a: unsupported value var.b, only literals, lists and objects are allowed",
+		},
+		{
+			name:  "function calls in a list",
+			input: `a = ["b", upper("c")]`,
+			err:   "line 1: a: unsupported value upper, only literals, lists and objects are allowed",
+		},
+		{
+			name:  "references in an object",
+			input: "a = {\n  b = local.c\n}\n",
+			err:   "line 1: a: unsupported value local.c, only literals, lists and objects are allowed",
+		},
+		{
+			name:  "templates in a list",
+			input: `a = ["${b}"]`,
+			err:   "line 1: a: templates are not allowed in variable files, escape ${ as $${",
+		},
+		{
+			name:  "parenthesized expression",
+			input: "a = (local.b)\n",
+			err:   "line 1: a: unexpected '('",
+		},
+		{
+			name:  "computed object key",
+			input: "a = { (var.k) = 1 }\n",
+			err:   "line 1: a: expected an object key but found '('",
+		},
+		{
+			name:  "missing comma in a list",
+			input: "a = [1 2]\n",
+			err:   "line 1: a: expected ',' or ']' but found '2'",
+		},
+		{
+			name:  "missing object separator",
+			input: "a = { b = 1 c = 2 }\n",
+			err:   "line 1: a: expected ',' or '}' but found 'c'",
+		},
+		{
+			name:  "not a number",
+			input: "a = inf\n",
+			err:   "line 1: a: unsupported value inf, only literals, lists and objects are allowed",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseTfvars([]byte(tc.input))
+
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseTfvarsJSON(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  []Variable
+		err   bool
+	}{
+		{
+			name:  "values",
+			input: `{"s": "a\"b", "n": 1.5, "b": false, "l": [1, "x"], "o": {"k": {"n": null}}, "z": null}`,
+			want: []Variable{
+				{Key: "b", Value: "false"},
+				{Key: "l", Value: `[1,"x"]`, HCL: true},
+				{Key: "n", Value: "1.5"},
+				{Key: "o", Value: `{"k":{"n":null}}`, HCL: true},
+				{Key: "s", Value: `a"b`},
+			},
+		},
+		{
+			name:  "strings aren't templates",
+			input: `{"s": "${x}"}`,
+			want:  []Variable{{Key: "s", Value: "${x}"}},
+		},
+		{
+			name:  "invalid json",
+			input: `{"a": }`,
+			err:   true,
+		},
+		{
+			name:  "not an object",
+			input: `["a"]`,
+			err:   true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseTfvarsJSON([]byte(tc.input))
+
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseDotenv(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  []Variable
+		err   string
+	}{
+		{
+			name: "values",
+			input: `# comment
+PLAIN=value
+export EXPORTED=1
+SPACED = padded
+EMPTY=
+WITH_EQUALS=a=b
+`,
+			want: []Variable{
+				{Key: "PLAIN", Value: "value"},
+				{Key: "EXPORTED", Value: "1"},
+				{Key: "SPACED", Value: "padded"},
+				{Key: "EMPTY", Value: ""},
+				{Key: "WITH_EQUALS", Value: "a=b"},
+			},
+		},
+		{
+			name:  "comments after values",
+			input: "A=value # comment\nB=value#not-a-comment\nC=\"quoted # kept\" # comment\n",
+			want: []Variable{
+				{Key: "A", Value: "value"},
+				{Key: "B", Value: "value#not-a-comment"},
+				{Key: "C", Value: "quoted # kept"},
+			},
+		},
+		{
+			name:  "double quoted escapes",
+			input: `A="line\nnext \"q\" back\\slash \d \x41"` + "\n",
+			want:  []Variable{{Key: "A", Value: "line\nnext \"q\" back\\slash \\d \\x41"}},
+		},
+		{
+			name:  "single quoted values are literal",
+			input: `A='no\nescape "here"'` + "\n",
+			want:  []Variable{{Key: "A", Value: `no\nescape "here"`}},
+		},
+		{
+			name:  "missing equals",
+			input: "A\n",
+			err:   "line 1: expected KEY=VALUE",
+		},
+		{
+			name:  "missing key",
+			input: "=x\n",
+			err:   "line 1: expected KEY=VALUE",
+		},
+		{
+			name:  "unterminated double quote",
+			input: "A=\"x\n",
+			err:   "line 1: unterminated quoted value for A",
+		},
+		{
+			name:  "unterminated single quote",
+			input: "\nA='x\n",
+			err:   "line 2: unterminated quoted value for A",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseDotenv([]byte(tc.input))
+
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPlanVariableChanges(t *testing.T) {
+	existing := []Variable{
+		{ID: "var-1", Key: "same", Value: "a", Category: tfe.CategoryTerraform},
+		{ID: "var-2", Key: "changed", Value: "a", Category: tfe.CategoryTerraform},
+		{ID: "var-3", Key: "secret", Category: tfe.CategoryTerraform, Sensitive: true},
+		{ID: "var-4", Key: "other", Value: "a", Category: tfe.CategoryEnv},
+		{ID: "var-5", Key: "hcl", Value: "[1]", Category: tfe.CategoryTerraform},
+	}
+
+	desired := []Variable{
+		{Key: "same", Value: "a", Category: tfe.CategoryTerraform},
+		{Key: "changed", Value: "b", Category: tfe.CategoryTerraform},
+		{Key: "secret", Value: "s", Category: tfe.CategoryTerraform},
+		{Key: "other", Value: "a", Category: tfe.CategoryTerraform},
+		{Key: "hcl", Value: "[1]", Category: tfe.CategoryTerraform, HCL: true},
+	}
+
+	want := []VariableChange{
+		{Key: "same", Category: tfe.CategoryTerraform, Action: "unchanged", VariableID: "var-1", OldValue: "a", NewValue: "a"},
+		{Key: "changed", Category: tfe.CategoryTerraform, Action: "update", VariableID: "var-2", OldValue: "a", NewValue: "b"},
+		{Key: "secret", Category: tfe.CategoryTerraform, Action: "update", VariableID: "var-3", NewValue: "s", Sensitive: true},
+		{Key: "other", Category: tfe.CategoryTerraform, Action: "create", NewValue: "a"},
+		{Key: "hcl", Category: tfe.CategoryTerraform, Action: "update", VariableID: "var-5", OldValue: "[1]", NewValue: "[1]", HCL: true},
+	}
+
+	require.Equal(t, want, planVariableChanges(existing, desired))
+
+	masked := maskVariableChanges(want)
+	require.Equal(t, "b", masked[1].NewValue)
+	require.Equal(t, sensitiveValue, masked[2].OldValue)
+	require.Equal(t, sensitiveValue, masked[2].NewValue)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Prefixes of variable values that are read from a local secret source
+// instead of being used as is. A value starting with a backslash followed by
+// a prefix, such as \cmd:x, is the literal value without the backslash.
+var valueSourcePrefixes = []string{"env:", "file:", "cmd:", "sops:"}
+
+// resolveVariableSource replaces a value referencing a secret source with the
+// value read from it and marks the variable as sensitive. Escaped values are
+// unescaped and other values are left untouched.
+func resolveVariableSource(value *string, sensitive *bool) error {
+	resolved, ok, err := resolveValueSource(*value)
+	if err != nil {
+		return err
+	}
+
+	*value = resolved
+	if ok {
+		*sensitive = true
+	}
+
+	return nil
+}
+
+// resolveValueSource reads the value referenced by env:NAME, file:/path,
+// cmd:command or sops:file#key and reports whether value was a reference.
+func resolveValueSource(value string) (string, bool, error) {
+	if isEscapedValueSource(value) {
+		return value[1:], false, nil
+	}
+
+	prefix := valueSourcePrefix(value)
+	if prefix == "" {
+		return value, false, nil
+	}
+
+	ref := strings.TrimPrefix(value, prefix)
+	if ref == "" {
+		return "", true, fmt.Errorf("missing reference after %s", prefix)
+	}
+
+	switch prefix {
+	case "env:":
+		v, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", true, fmt.Errorf("environment variable %s is not set", ref)
+		}
+		return v, true, nil
+	case "file:":
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return "", true, err
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	case "cmd:":
+		v, err := runValueSourceCommand("sh", "-c", ref)
+		return v, true, err
+	default:
+		file, key, _ := strings.Cut(ref, "#")
+		args := []string{"--decrypt"}
+		if key != "" {
+			args = append(args, "--extract", sopsExtractPath(key))
+		}
+		v, err := runValueSourceCommand("sops", append(args, file)...)
+		return v, true, err
+	}
+}
+
+// valueSourcePrefix returns the source prefix of value or an empty string
+func valueSourcePrefix(value string) string {
+	for _, p := range valueSourcePrefixes {
+		if strings.HasPrefix(value, p) {
+			return p
+		}
+	}
+
+	return ""
+}
+
+// isEscapedValueSource reports whether value is one or more backslashes
+// followed by a source prefix
+func isEscapedValueSource(value string) bool {
+	trimmed := strings.TrimLeft(value, "\\")
+	return trimmed != value && valueSourcePrefix(trimmed) != ""
+}
+
+// escapeValueSource escapes a literal value so that it isn't read as a
+// source reference by resolveValueSource
+func escapeValueSource(value string) string {
+	if valueSourcePrefix(value) != "" || isEscapedValueSource(value) {
+		return "\\" + value
+	}
+
+	return value
+}
+
+// runValueSourceCommand returns the output of the command without the trailing newline
+func runValueSourceCommand(name string, args ...string) (string, error) {
+	c := exec.Command(name, args...)
+	c.Stderr = os.Stderr
+
+	out, err := c.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v", name, err)
+	}
+
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// sopsExtractPath converts a dotted key such as db.password to the
+// ["db"]["password"] syntax of sops --extract
+func sopsExtractPath(key string) string {
+	var path strings.Builder
+	for _, k := range strings.Split(key, ".") {
+		fmt.Fprintf(&path, "[%q]", k)
+	}
+
+	return path.String()
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveValueSource(t *testing.T) {
+	t.Setenv("TFECTL_TEST_SECRET", "from-env")
+
+	file := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(file, []byte("from-file\n"), 0600))
+
+	tt := []struct {
+		name  string
+		value string
+		want  string
+		ok    bool
+		err   string
+	}{
+		{name: "literal", value: "plain", want: "plain"},
+		{name: "prefix in the middle", value: "x env:TFECTL_TEST_SECRET", want: "x env:TFECTL_TEST_SECRET"},
+		{name: "env", value: "env:TFECTL_TEST_SECRET", want: "from-env", ok: true},
+		{name: "file", value: "file:" + file, want: "from-file", ok: true},
+		{name: "escaped", value: `\cmd:rm -rf /`, want: "cmd:rm -rf /"},
+		{name: "escaped backslash", value: `\\env:TFECTL_TEST_SECRET`, want: `\env:TFECTL_TEST_SECRET`},
+		{name: "backslash without prefix", value: `\n`, want: `\n`},
+		{name: "missing env", value: "env:TFECTL_TEST_MISSING", ok: true, err: "environment variable TFECTL_TEST_MISSING is not set"},
+		{name: "missing reference", value: "file:", ok: true, err: "missing reference after file:"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok, err := resolveValueSource(tc.value)
+			require.Equal(t, tc.ok, ok)
+
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestResolveVariableSource(t *testing.T) {
+	t.Setenv("TFECTL_TEST_SECRET", "from-env")
+
+	value, sensitive := "env:TFECTL_TEST_SECRET", false
+	require.NoError(t, resolveVariableSource(&value, &sensitive))
+	require.Equal(t, "from-env", value)
+	require.True(t, sensitive)
+
+	value, sensitive = `\env:TFECTL_TEST_SECRET`, false
+	require.NoError(t, resolveVariableSource(&value, &sensitive))
+	require.Equal(t, "env:TFECTL_TEST_SECRET", value)
+	require.False(t, sensitive)
+}
+
+func TestEscapeValueSource(t *testing.T) {
+	for _, value := range []string{"plain", `\n`, "cmd:x", `\cmd:x`, `\\file:/etc/passwd`, "sops:a#b"} {
+		escaped := escapeValueSource(value)
+
+		got, ok, err := resolveValueSource(escaped)
+		require.NoError(t, err)
+		require.False(t, ok, value)
+		require.Equal(t, value, got)
+	}
+}
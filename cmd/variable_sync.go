@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type VariableDiff struct {
+	Key            string           `json:"key"`
+	Category       tfe.CategoryType `json:"category"`
+	Status         string           `json:"status"`
+	Differences    []string         `json:"differences"`
+	SourceValue    string           `json:"source_value"`
+	TargetValue    string           `json:"target_value"`
+	SourceCategory tfe.CategoryType `json:"source_category"`
+	TargetCategory tfe.CategoryType `json:"target_category"`
+}
+
+type WorkspaceVariableChanges struct {
+	WorkspaceLite
+	Changes []VariableChange `json:"changes"`
+}
+
+var variableDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the variables of two TFE workspaces",
+	Long: `Compare the variables of two TFE workspaces by key.
+Variables are reported as missing from the target, extra in the target or different
+in value, hcl, sensitive or category. Identical variables are only reported with --all.
+The values of sensitive variables can't be read, so they're reported as unknown
+unless their flags differ.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		source, _ := cmd.Flags().GetString("source")
+		target, _ := cmd.Flags().GetString("target")
+		all, _ := cmd.Flags().GetBool("all")
+
+		if source == "" || target == "" {
+			log.Fatal("please provide source and target to perform this operation!")
+		}
+
+		sourceVars, err := listWorkspaceVariables(client, organization, source)
+		check(err)
+
+		targetVars, err := listWorkspaceVariables(client, organization, target)
+		check(err)
+
+		results := []VariableDiff{}
+		for _, d := range diffVariables(sourceVars.Variables, targetVars.Variables) {
+			if d.Status == "identical" && !all {
+				continue
+			}
+			results = append(results, d)
+		}
+
+		diffJson, _ := json.MarshalIndent(results, "", "  ")
+		outputData(cmd, diffJson)
+	},
+}
+
+var variableSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Copy variables from one TFE workspace to others",
+	Long: `Copy variables from a source TFE workspace to target workspaces.
+Variables are matched on key and category: missing variables are created and
+variables with a different value or description are updated. Variables only in the targets are left untouched.
+--include and --exclude take comma separated lists of key globs.
+The values of sensitive variables can't be read, so they have to be provided
+with --sensitive-values, .tfvars or .tfvars.json files for terraform variables and .env files
+for environment variables. Sensitive variables without a value are skipped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		source, _ := cmd.Flags().GetString("source")
+		targets, _ := cmd.Flags().GetString("targets")
+		include, _ := cmd.Flags().GetString("include")
+		exclude, _ := cmd.Flags().GetString("exclude")
+		sensitiveValues, _ := cmd.Flags().GetStringSlice("sensitive-values")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if source == "" || targets == "" {
+			log.Fatal("please provide source and targets to perform this operation!")
+		}
+
+		sourceVars, err := listWorkspaceVariables(client, organization, source)
+		check(err)
+
+		// Sensitive values by category and key
+		values := map[string]string{}
+		for _, file := range sensitiveValues {
+			format := detectVariableFileFormat(file)
+			category := tfe.CategoryTerraform
+			if format == "env" {
+				category = tfe.CategoryEnv
+			}
+
+			vars, err := readVariableFile(file, format)
+			check(err)
+
+			for _, v := range vars {
+				err = resolveVariableSource(&v.Value, &v.Sensitive)
+				check(err)
+
+				values[syncValueKey(category, v.Key)] = v.Value
+			}
+		}
+
+		desired := selectSyncVariables(sourceVars.Variables, splitIDs(include), splitIDs(exclude), values)
+
+		results := []WorkspaceVariableChanges{}
+		for _, target := range splitIDs(targets) {
+			if target == source {
+				log.Warnf("Skipping %s: the target is the source workspace", target)
+				continue
+			}
+
+			targetVars, err := listWorkspaceVariables(client, organization, target)
+			check(err)
+
+			changes := planSyncChanges(targetVars.Variables, desired)
+
+			if !dryRun {
+				for i, c := range changes {
+					changes[i].VariableID, err = applyVariableChangeWithDescription(client, target, c)
+					check(err)
+				}
+			}
+
+			results = append(results, WorkspaceVariableChanges{
+				WorkspaceLite: targetVars.WorkspaceLite,
+				Changes:       maskVariableChanges(changes),
+			})
+		}
+
+		resultsJson, _ := json.MarshalIndent(results, "", "  ")
+		outputData(cmd, resultsJson)
+	},
+}
+
+func init() {
+	variableCmd.AddCommand(variableDiffCmd)
+	variableDiffCmd.Flags().String("source", "", "workspaceID of the source workspace")
+	variableDiffCmd.Flags().String("target", "", "workspaceID of the target workspace")
+	variableDiffCmd.Flags().Bool("all", false, "Also report identical variables")
+
+	variableCmd.AddCommand(variableSyncCmd)
+	variableSyncCmd.Flags().String("source", "", "workspaceID of the source workspace")
+	variableSyncCmd.Flags().String("targets", "", "Comma separated list of target workspaceIDs")
+	variableSyncCmd.Flags().String("include", "", "Comma separated list of key globs to copy, defaults to all keys")
+	variableSyncCmd.Flags().String("exclude", "", "Comma separated list of key globs not to copy")
+	variableSyncCmd.Flags().StringSlice("sensitive-values", nil, "Files with the values of sensitive variables, .env files for env variables and .tfvars or .tfvars.json files for terraform variables")
+	variableSyncCmd.Flags().Bool("dry-run", false, "Only show the changes, don't apply them")
+}
+
+// diffVariables compares variables by key. Variables with the same key in
+// different categories are reported as a category difference.
+func diffVariables(source []Variable, target []Variable) []VariableDiff {
+	results := []VariableDiff{}
+	matched := map[string]bool{}
+
+	// Exact matches take precedence over matches in another category
+	for _, s := range source {
+		if t, ok := findVariable(target, s.Key, s.Category); ok {
+			matched[t.ID] = true
+		}
+	}
+
+	for _, s := range source {
+		t, ok := findVariable(target, s.Key, s.Category)
+		if !ok {
+			for _, v := range target {
+				if v.Key == s.Key && !matched[v.ID] {
+					t, ok = v, true
+					break
+				}
+			}
+		}
+
+		if !ok {
+			results = append(results, VariableDiff{
+				Key:            s.Key,
+				Category:       s.Category,
+				Status:         "missing",
+				Differences:    []string{},
+				SourceValue:    variableDisplayValue(s),
+				SourceCategory: s.Category,
+			})
+			continue
+		}
+		matched[t.ID] = true
+
+		d := VariableDiff{
+			Key:            s.Key,
+			Category:       s.Category,
+			Status:         "identical",
+			Differences:    []string{},
+			SourceValue:    variableDisplayValue(s),
+			TargetValue:    variableDisplayValue(t),
+			SourceCategory: s.Category,
+			TargetCategory: t.Category,
+		}
+
+		if s.Category != t.Category {
+			d.Differences = append(d.Differences, "category")
+		}
+		if s.HCL != t.HCL {
+			d.Differences = append(d.Differences, "hcl")
+		}
+		if s.Sensitive != t.Sensitive {
+			d.Differences = append(d.Differences, "sensitive")
+		}
+		if !s.Sensitive && !t.Sensitive && s.Value != t.Value {
+			d.Differences = append(d.Differences, "value")
+		}
+
+		if len(d.Differences) > 0 {
+			d.Status = "different"
+		} else if s.Sensitive {
+			d.Status = "unknown"
+		}
+
+		results = append(results, d)
+	}
+
+	for _, t := range target {
+		if matched[t.ID] {
+			continue
+		}
+
+		results = append(results, VariableDiff{
+			Key:            t.Key,
+			Category:       t.Category,
+			Status:         "extra",
+			Differences:    []string{},
+			TargetValue:    variableDisplayValue(t),
+			TargetCategory: t.Category,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Key < results[j].Key
+	})
+
+	return results
+}
+
+func findVariable(variables []Variable, key string, category tfe.CategoryType) (Variable, bool) {
+	for _, v := range variables {
+		if v.Key == key && v.Category == category {
+			return v, true
+		}
+	}
+
+	return Variable{}, false
+}
+
+func variableDisplayValue(v Variable) string {
+	if v.Sensitive {
+		return sensitiveValue
+	}
+
+	return v.Value
+}
+
+// selectSyncVariables returns the source variables to copy, filtered on key
+// and with the values of sensitive variables taken from values.
+func selectSyncVariables(source []Variable, include []string, exclude []string, values map[string]string) []Variable {
+	var results []Variable
+
+	for _, v := range source {
+		if len(include) > 0 && !matchesAnyGlob(include, v.Key) {
+			continue
+		}
+		if matchesAnyGlob(exclude, v.Key) {
+			continue
+		}
+
+		if v.Sensitive {
+			value, ok := values[syncValueKey(v.Category, v.Key)]
+			if !ok {
+				log.Warnf("Skipping %s: sensitive values can't be read, provide it with --sensitive-values", v.Key)
+				continue
+			}
+			v.Value = value
+		}
+
+		results = append(results, v)
+	}
+
+	return results
+}
+
+// syncValueKey is the key of a sensitive value in the values of selectSyncVariables
+func syncValueKey(category tfe.CategoryType, key string) string {
+	return string(category) + "/" + key
+}
+
+// planSyncChanges plans the changes of a target workspace like
+// planVariableChanges, also updating variables whose description differs
+// from the description of the source variable.
+func planSyncChanges(target []Variable, desired []Variable) []VariableChange {
+	results := planVariableChanges(target, desired)
+
+	for i, c := range results {
+		d, _ := findVariable(desired, c.Key, c.Category)
+		results[i].Description = d.Description
+
+		if t, ok := findVariable(target, c.Key, c.Category); ok && c.Action == "unchanged" && t.Description != d.Description {
+			results[i].Action = "update"
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffVariables(t *testing.T) {
+	tt := []struct {
+		name   string
+		source []Variable
+		target []Variable
+		want   []VariableDiff
+	}{
+		{
+			name:   "identical",
+			source: []Variable{{ID: "s1", Key: "a", Value: "x", Category: tfe.CategoryEnv}},
+			target: []Variable{{ID: "t1", Key: "a", Value: "x", Category: tfe.CategoryEnv}},
+			want: []VariableDiff{
+				{Key: "a", Category: tfe.CategoryEnv, Status: "identical", Differences: []string{}, SourceValue: "x", TargetValue: "x", SourceCategory: tfe.CategoryEnv, TargetCategory: tfe.CategoryEnv},
+			},
+		},
+		{
+			name:   "missing and extra",
+			source: []Variable{{ID: "s1", Key: "a", Value: "x", Category: tfe.CategoryEnv}},
+			target: []Variable{{ID: "t1", Key: "b", Value: "y", Category: tfe.CategoryEnv}},
+			want: []VariableDiff{
+				{Key: "a", Category: tfe.CategoryEnv, Status: "missing", Differences: []string{}, SourceValue: "x", SourceCategory: tfe.CategoryEnv},
+				{Key: "b", Category: tfe.CategoryEnv, Status: "extra", Differences: []string{}, TargetValue: "y", TargetCategory: tfe.CategoryEnv},
+			},
+		},
+		{
+			name:   "value, hcl and sensitive differences",
+			source: []Variable{{ID: "s1", Key: "a", Value: "[1]", Category: tfe.CategoryTerraform, HCL: true}, {ID: "s2", Key: "b", Value: "x", Category: tfe.CategoryTerraform}},
+			target: []Variable{{ID: "t1", Key: "a", Value: "[2]", Category: tfe.CategoryTerraform}, {ID: "t2", Key: "b", Category: tfe.CategoryTerraform, Sensitive: true}},
+			want: []VariableDiff{
+				{Key: "a", Category: tfe.CategoryTerraform, Status: "different", Differences: []string{"hcl", "value"}, SourceValue: "[1]", TargetValue: "[2]", SourceCategory: tfe.CategoryTerraform, TargetCategory: tfe.CategoryTerraform},
+				{Key: "b", Category: tfe.CategoryTerraform, Status: "different", Differences: []string{"sensitive"}, SourceValue: "x", TargetValue: sensitiveValue, SourceCategory: tfe.CategoryTerraform, TargetCategory: tfe.CategoryTerraform},
+			},
+		},
+		{
+			name:   "sensitive values are unknown",
+			source: []Variable{{ID: "s1", Key: "a", Category: tfe.CategoryEnv, Sensitive: true}},
+			target: []Variable{{ID: "t1", Key: "a", Category: tfe.CategoryEnv, Sensitive: true}},
+			want: []VariableDiff{
+				{Key: "a", Category: tfe.CategoryEnv, Status: "unknown", Differences: []string{}, SourceValue: sensitiveValue, TargetValue: sensitiveValue, SourceCategory: tfe.CategoryEnv, TargetCategory: tfe.CategoryEnv},
+			},
+		},
+		{
+			name:   "same key in another category",
+			source: []Variable{{ID: "s1", Key: "a", Value: "x", Category: tfe.CategoryEnv}},
+			target: []Variable{{ID: "t1", Key: "a", Value: "x", Category: tfe.CategoryTerraform}},
+			want: []VariableDiff{
+				{Key: "a", Category: tfe.CategoryEnv, Status: "different", Differences: []string{"category"}, SourceValue: "x", TargetValue: "x", SourceCategory: tfe.CategoryEnv, TargetCategory: tfe.CategoryTerraform},
+			},
+		},
+		{
+			name: "exact matches take precedence over other categories",
+			source: []Variable{
+				{ID: "s1", Key: "a", Value: "x", Category: tfe.CategoryEnv},
+				{ID: "s2", Key: "a", Value: "y", Category: tfe.CategoryTerraform},
+			},
+			target: []Variable{{ID: "t1", Key: "a", Value: "y", Category: tfe.CategoryTerraform}},
+			want: []VariableDiff{
+				{Key: "a", Category: tfe.CategoryEnv, Status: "missing", Differences: []string{}, SourceValue: "x", SourceCategory: tfe.CategoryEnv},
+				{Key: "a", Category: tfe.CategoryTerraform, Status: "identical", Differences: []string{}, SourceValue: "y", TargetValue: "y", SourceCategory: tfe.CategoryTerraform, TargetCategory: tfe.CategoryTerraform},
+			},
+		},
+		{
+			name:   "empty workspaces",
+			source: nil,
+			target: nil,
+			want:   []VariableDiff{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, diffVariables(tc.source, tc.target))
+		})
+	}
+}
+
+func TestSelectSyncVariables(t *testing.T) {
+	source := []Variable{
+		{Key: "AWS_REGION", Value: "eu-west-1", Category: tfe.CategoryEnv},
+		{Key: "AWS_SECRET_ACCESS_KEY", Category: tfe.CategoryEnv, Sensitive: true},
+		{Key: "DB_PASSWORD", Category: tfe.CategoryEnv, Sensitive: true},
+		{Key: "DB_PASSWORD", Category: tfe.CategoryTerraform, Sensitive: true},
+		{Key: "name", Value: "app", Category: tfe.CategoryTerraform},
+	}
+
+	values := map[string]string{
+		syncValueKey(tfe.CategoryEnv, "DB_PASSWORD"):       "env-s3cret",
+		syncValueKey(tfe.CategoryTerraform, "DB_PASSWORD"): "tf-s3cret",
+	}
+
+	got := selectSyncVariables(source, []string{"AWS_*", "DB_*"}, []string{"*REGION"}, values)
+
+	require.Equal(t, []Variable{
+		{Key: "DB_PASSWORD", Value: "env-s3cret", Category: tfe.CategoryEnv, Sensitive: true},
+		{Key: "DB_PASSWORD", Value: "tf-s3cret", Category: tfe.CategoryTerraform, Sensitive: true},
+	}, got)
+
+	// Values are matched on category too
+	got = selectSyncVariables(source, []string{"DB_*"}, nil, map[string]string{syncValueKey(tfe.CategoryEnv, "DB_PASSWORD"): "env-s3cret"})
+	require.Equal(t, []Variable{
+		{Key: "DB_PASSWORD", Value: "env-s3cret", Category: tfe.CategoryEnv, Sensitive: true},
+	}, got)
+
+	require.Len(t, selectSyncVariables(source, nil, nil, nil), 2)
+}
+
+func TestPlanSyncChanges(t *testing.T) {
+	target := []Variable{
+		{ID: "var-1", Key: "same", Value: "a", Description: "d", Category: tfe.CategoryTerraform},
+		{ID: "var-2", Key: "description", Value: "a", Description: "old", Category: tfe.CategoryTerraform},
+		{ID: "var-3", Key: "value", Value: "a", Description: "d", Category: tfe.CategoryEnv},
+	}
+
+	desired := []Variable{
+		{Key: "same", Value: "a", Description: "d", Category: tfe.CategoryTerraform},
+		{Key: "description", Value: "a", Description: "new", Category: tfe.CategoryTerraform},
+		{Key: "value", Value: "b", Description: "d", Category: tfe.CategoryEnv},
+		{Key: "missing", Value: "c", Description: "m", Category: tfe.CategoryEnv},
+	}
+
+	require.Equal(t, []VariableChange{
+		{Key: "same", Category: tfe.CategoryTerraform, Action: "unchanged", VariableID: "var-1", OldValue: "a", NewValue: "a", Description: "d"},
+		{Key: "description", Category: tfe.CategoryTerraform, Action: "update", VariableID: "var-2", OldValue: "a", NewValue: "a", Description: "new"},
+		{Key: "value", Category: tfe.CategoryEnv, Action: "update", VariableID: "var-3", OldValue: "a", NewValue: "b", Description: "d"},
+		{Key: "missing", Category: tfe.CategoryEnv, Action: "create", NewValue: "c", Description: "m"},
+	}, planSyncChanges(target, desired))
+}
@@ -0,0 +1,709 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/AGLEnergyPublic/tfectl/resources"
+	tfe "github.com/hashicorp/go-tfe"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type VariableSet struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Global         bool     `json:"global"`
+	Priority       bool     `json:"priority"`
+	Workspaces     []string `json:"workspaces"`
+	WorkspaceCount int      `json:"workspace_count"`
+	Projects       []string `json:"projects"`
+	ProjectCount   int      `json:"project_count"`
+	VariableCount  int      `json:"variable_count"`
+}
+
+type VariableSetVars struct {
+	VariableSet
+	Variables []Variable `json:"variables"`
+}
+
+type VariableSetWorkspace struct {
+	WorkspaceLite
+	Via string `json:"via"`
+}
+
+type VariableSetUsage struct {
+	VariableSetID   string                 `json:"varset_id"`
+	VariableSetName string                 `json:"varset_name"`
+	Global          bool                   `json:"global"`
+	Priority        bool                   `json:"priority"`
+	WorkspaceCount  int                    `json:"workspace_count"`
+	Workspaces      []VariableSetWorkspace `json:"workspaces"`
+}
+
+var varsetCmd = &cobra.Command{
+	Use:   "varset",
+	Short: "Manage TFE variable sets",
+	Long:  `Manage TFE variable sets.`,
+}
+
+var varsetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List variable sets in a TFE Organization",
+	Long:  `List variable sets in a TFE Organization.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		filter, _ := cmd.Flags().GetString("filter")
+
+		variableSets, err := listVariableSets(client, organization, filter)
+		check(err)
+
+		varsetList := []VariableSet{}
+		for _, vs := range variableSets {
+			log.Debugf("Processing variable set: %s - %s", vs.Name, vs.ID)
+			varsetList = append(varsetList, newVariableSet(vs))
+		}
+
+		varsetListJson, _ := json.MarshalIndent(varsetList, "", "  ")
+		outputData(cmd, varsetListJson)
+	},
+}
+
+var varsetGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get a TFE variable set and its variables",
+	Long: `Get a TFE variable set and its variables.
+The values of sensitive variables are never returned.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+
+		outputVariableSet(cmd, client, id)
+	},
+}
+
+var varsetCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a TFE variable set",
+	Long:  `Create a TFE variable set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		name, _ := cmd.Flags().GetString("name")
+		description, _ := cmd.Flags().GetString("description")
+		global, _ := cmd.Flags().GetBool("global")
+		priority, _ := cmd.Flags().GetBool("priority")
+		workspaces, _ := cmd.Flags().GetString("workspaces")
+		projects, _ := cmd.Flags().GetString("projects")
+
+		if name == "" {
+			log.Fatal("please provide the name of the variable set to perform this operation!")
+		}
+
+		log.Debugf("Creating variable set: %s", name)
+		vs, err := client.VariableSets.Create(context.Background(), organization, &tfe.VariableSetCreateOptions{
+			Name:        &name,
+			Description: &description,
+			Global:      &global,
+			Priority:    &priority,
+		})
+		check(err)
+
+		err = applyVariableSet(client, vs.ID, splitIDs(workspaces), splitIDs(projects))
+		check(err)
+
+		outputVariableSet(cmd, client, vs.ID)
+	},
+}
+
+var varsetUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update a TFE variable set",
+	Long: `Update a TFE variable set.
+Only the given flags are updated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+		name, _ := cmd.Flags().GetString("name")
+		description, _ := cmd.Flags().GetString("description")
+		global, _ := cmd.Flags().GetBool("global")
+		priority, _ := cmd.Flags().GetBool("priority")
+
+		options := &tfe.VariableSetUpdateOptions{}
+
+		if cmd.Flags().Changed("name") {
+			options.Name = &name
+		}
+		if cmd.Flags().Changed("description") {
+			options.Description = &description
+		}
+		if cmd.Flags().Changed("global") {
+			options.Global = &global
+		}
+		if cmd.Flags().Changed("priority") {
+			options.Priority = &priority
+		}
+
+		log.Debugf("Updating variable set: %s", id)
+		_, err = client.VariableSets.Update(context.Background(), id, options)
+		check(err)
+
+		outputVariableSet(cmd, client, id)
+	},
+}
+
+var varsetDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a TFE variable set",
+	Long:  `Delete a TFE variable set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, _ := cmd.Flags().GetString("id")
+
+		log.Debugf("Deleting variable set: %s", id)
+		err = client.VariableSets.Delete(context.Background(), id)
+		check(err)
+
+		log.Infof("Deleted variable set %s", id)
+	},
+}
+
+var varsetApplyToCmd = &cobra.Command{
+	Use:   "apply-to",
+	Short: "Apply a TFE variable set to workspaces and projects",
+	Long:  `Apply a TFE variable set to workspaces and projects.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, workspaces, projects := getVariableSetScopeFlags(cmd)
+
+		err = applyVariableSet(client, id, workspaces, projects)
+		check(err)
+
+		outputVariableSet(cmd, client, id)
+	},
+}
+
+var varsetRemoveFromCmd = &cobra.Command{
+	Use:   "remove-from",
+	Short: "Remove a TFE variable set from workspaces and projects",
+	Long:  `Remove a TFE variable set from workspaces and projects.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		id, workspaces, projects := getVariableSetScopeFlags(cmd)
+
+		err = removeVariableSet(client, id, workspaces, projects)
+		check(err)
+
+		outputVariableSet(cmd, client, id)
+	},
+}
+
+var varsetUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show which workspaces each variable set reaches",
+	Long: `Show which workspaces each variable set reaches, either because the set is global,
+applied to the project of the workspace or applied to the workspace itself.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		organization, client, err := resources.Setup(cmd)
+		check(err)
+
+		filter, _ := cmd.Flags().GetString("filter")
+
+		usage, err := getVariableSetUsage(client, organization, filter)
+		check(err)
+
+		usageJson, _ := json.MarshalIndent(usage, "", "  ")
+		outputData(cmd, usageJson)
+	},
+}
+
+var varsetVariableCmd = &cobra.Command{
+	Use:   "variable",
+	Short: "Manage variables of TFE variable sets",
+	Long:  `Manage variables of TFE variable sets.`,
+}
+
+var varsetVariableListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List variables of a TFE variable set",
+	Long:  `List variables of a TFE variable set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		varsetID, _ := cmd.Flags().GetString("varset-id")
+
+		variables, err := listVariableSetVariables(client, varsetID)
+		check(err)
+
+		variablesJson, _ := json.MarshalIndent(variables, "", "  ")
+		outputData(cmd, variablesJson)
+	},
+}
+
+var varsetVariableCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a variable in a TFE variable set",
+	Long:  `Create a variable in a TFE variable set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		varsetID, _ := cmd.Flags().GetString("varset-id")
+		key, _ := cmd.Flags().GetString("key")
+		value, _ := cmd.Flags().GetString("value")
+		description, _ := cmd.Flags().GetString("description")
+		categoryTypeStr, _ := cmd.Flags().GetString("type")
+		hcl, _ := cmd.Flags().GetBool("hcl")
+		sensitive, _ := cmd.Flags().GetBool("sensitive")
+
+		categoryType := tfe.CategoryType(categoryTypeStr)
+
+		err = resolveVariableSource(&value, &sensitive)
+		check(err)
+
+		log.Debugf("Creating variable %s in variable set: %s", key, varsetID)
+		v, err := client.VariableSetVariables.Create(context.Background(), varsetID, &tfe.VariableSetVariableCreateOptions{
+			Key:         &key,
+			Value:       &value,
+			Description: &description,
+			Category:    &categoryType,
+			HCL:         &hcl,
+			Sensitive:   &sensitive,
+		})
+		check(err)
+
+		variableJson, _ := json.MarshalIndent(newVariableSetVariable(v), "", "  ")
+		outputData(cmd, variableJson)
+	},
+}
+
+var varsetVariableUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update a variable in a TFE variable set",
+	Long: `Update a variable in a TFE variable set.
+Only the given flags are updated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		varsetID, _ := cmd.Flags().GetString("varset-id")
+		variableID, _ := cmd.Flags().GetString("variable-id")
+		key, _ := cmd.Flags().GetString("key")
+		value, _ := cmd.Flags().GetString("value")
+		description, _ := cmd.Flags().GetString("description")
+		hcl, _ := cmd.Flags().GetBool("hcl")
+		sensitive, _ := cmd.Flags().GetBool("sensitive")
+
+		options := &tfe.VariableSetVariableUpdateOptions{}
+
+		if cmd.Flags().Changed("key") {
+			options.Key = &key
+		}
+		if cmd.Flags().Changed("value") {
+			err = resolveVariableSource(&value, &sensitive)
+			check(err)
+
+			options.Value = &value
+			if sensitive {
+				options.Sensitive = &sensitive
+			}
+		}
+		if cmd.Flags().Changed("description") {
+			options.Description = &description
+		}
+		if cmd.Flags().Changed("hcl") {
+			options.HCL = &hcl
+		}
+		if cmd.Flags().Changed("sensitive") {
+			options.Sensitive = &sensitive
+		}
+
+		log.Debugf("Updating variable %s in variable set: %s", variableID, varsetID)
+		v, err := client.VariableSetVariables.Update(context.Background(), varsetID, variableID, options)
+		check(err)
+
+		variableJson, _ := json.MarshalIndent(newVariableSetVariable(v), "", "  ")
+		outputData(cmd, variableJson)
+	},
+}
+
+var varsetVariableDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a variable from a TFE variable set",
+	Long:  `Delete a variable from a TFE variable set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, client, err := resources.Setup(cmd)
+		check(err)
+
+		varsetID, _ := cmd.Flags().GetString("varset-id")
+		variableID, _ := cmd.Flags().GetString("variable-id")
+
+		log.Debugf("Deleting variable %s from variable set: %s", variableID, varsetID)
+		err = client.VariableSetVariables.Delete(context.Background(), varsetID, variableID)
+		check(err)
+
+		variables, err := listVariableSetVariables(client, varsetID)
+		check(err)
+
+		variablesJson, _ := json.MarshalIndent(variables, "", "  ")
+		outputData(cmd, variablesJson)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(varsetCmd)
+
+	// List sub-command
+	varsetCmd.AddCommand(varsetListCmd)
+	varsetListCmd.Flags().String("filter", "", "Search for variable sets by name")
+
+	// Get sub-command
+	varsetCmd.AddCommand(varsetGetCmd)
+	varsetGetCmd.Flags().String("id", "", "ID of the variable set")
+
+	// Create sub-command
+	varsetCmd.AddCommand(varsetCreateCmd)
+	varsetCreateCmd.Flags().String("name", "", "Name of the variable set")
+	varsetCreateCmd.Flags().String("description", "Variable Set Created by tfectl", "Description for the variable set")
+	varsetCreateCmd.Flags().Bool("global", false, "Apply the variable set to all workspaces of the organization")
+	varsetCreateCmd.Flags().Bool("priority", false, "Variables of the set override variables with the same key at a more specific scope")
+	varsetCreateCmd.Flags().String("workspaces", "", "Comma separated list of workspaceIDs to apply the variable set to")
+	varsetCreateCmd.Flags().String("projects", "", "Comma separated list of projectIDs to apply the variable set to")
+
+	// Update sub-command
+	varsetCmd.AddCommand(varsetUpdateCmd)
+	varsetUpdateCmd.Flags().String("id", "", "ID of the variable set")
+	varsetUpdateCmd.Flags().String("name", "", "Name of the variable set")
+	varsetUpdateCmd.Flags().String("description", "", "Description for the variable set")
+	varsetUpdateCmd.Flags().Bool("global", false, "Apply the variable set to all workspaces of the organization")
+	varsetUpdateCmd.Flags().Bool("priority", false, "Variables of the set override variables with the same key at a more specific scope")
+
+	// Delete sub-command
+	varsetCmd.AddCommand(varsetDeleteCmd)
+	varsetDeleteCmd.Flags().String("id", "", "ID of the variable set")
+
+	// Apply-to and remove-from sub-commands
+	for _, c := range []*cobra.Command{varsetApplyToCmd, varsetRemoveFromCmd} {
+		varsetCmd.AddCommand(c)
+		c.Flags().String("id", "", "ID of the variable set")
+		c.Flags().String("workspaces", "", "Comma separated list of workspaceIDs")
+		c.Flags().String("projects", "", "Comma separated list of projectIDs")
+	}
+
+	// Usage sub-command
+	varsetCmd.AddCommand(varsetUsageCmd)
+	varsetUsageCmd.Flags().String("filter", "", "Search for variable sets by name")
+
+	// Variable sub-commands
+	varsetCmd.AddCommand(varsetVariableCmd)
+
+	varsetVariableCmd.AddCommand(varsetVariableListCmd)
+	varsetVariableListCmd.Flags().String("varset-id", "", "ID of the variable set")
+
+	varsetVariableCmd.AddCommand(varsetVariableCreateCmd)
+	varsetVariableCreateCmd.Flags().String("varset-id", "", "ID of the variable set")
+	varsetVariableCreateCmd.Flags().String("key", "", "Variable Name")
+	varsetVariableCreateCmd.Flags().String("value", "", "Variable Value")
+	varsetVariableCreateCmd.Flags().Bool("sensitive", false, "Set sensitive flag for variable")
+	varsetVariableCreateCmd.Flags().Bool("hcl", false, "Set if variable has HCL syntax")
+	varsetVariableCreateCmd.Flags().String("type", "env", "Variable type")
+	varsetVariableCreateCmd.Flags().String("description", "Variable Created by tfectl", "Description for the variable")
+
+	varsetVariableCmd.AddCommand(varsetVariableUpdateCmd)
+	varsetVariableUpdateCmd.Flags().String("varset-id", "", "ID of the variable set")
+	varsetVariableUpdateCmd.Flags().String("variable-id", "", "variableID")
+	varsetVariableUpdateCmd.Flags().String("key", "", "Variable Name")
+	varsetVariableUpdateCmd.Flags().String("value", "", "Variable Value")
+	varsetVariableUpdateCmd.Flags().Bool("sensitive", false, "Set sensitive flag for variable")
+	varsetVariableUpdateCmd.Flags().Bool("hcl", false, "Set if variable has HCL syntax")
+	varsetVariableUpdateCmd.Flags().String("description", "", "Description for the variable")
+
+	varsetVariableCmd.AddCommand(varsetVariableDeleteCmd)
+	varsetVariableDeleteCmd.Flags().String("varset-id", "", "ID of the variable set")
+	varsetVariableDeleteCmd.Flags().String("variable-id", "", "variableID of the variable")
+}
+
+func listVariableSets(client *tfe.Client, organization string, filter string) ([]*tfe.VariableSet, error) {
+	results := []*tfe.VariableSet{}
+	currentPage := 1
+
+	for {
+		log.Debugf("Processing page %d.\n", currentPage)
+		options := &tfe.VariableSetListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: currentPage,
+				PageSize:   50,
+			},
+			Include: strings.Join([]string{
+				string(tfe.VariableSetWorkspaces),
+				string(tfe.VariableSetProjects),
+				string(tfe.VariableSetVars),
+			}, ","),
+			Query: filter,
+		}
+
+		vs, err := client.VariableSets.List(context.Background(), organization, options)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, vs.Items...)
+
+		if vs.NextPage == 0 {
+			break
+		}
+
+		currentPage++
+	}
+
+	return results, nil
+}
+
+func readVariableSet(client *tfe.Client, variableSetID string) (*tfe.VariableSet, error) {
+	log.Debugf("Reading variable set: %s", variableSetID)
+	return client.VariableSets.Read(context.Background(), variableSetID, &tfe.VariableSetReadOptions{
+		Include: &[]tfe.VariableSetIncludeOpt{
+			tfe.VariableSetWorkspaces,
+			tfe.VariableSetProjects,
+			tfe.VariableSetVars,
+		},
+	})
+}
+
+func listVariableSetVariables(client *tfe.Client, variableSetID string) ([]Variable, error) {
+	results := []Variable{}
+	currentPage := 1
+
+	for {
+		log.Debugf("Processing page %d.\n", currentPage)
+		options := &tfe.VariableSetVariableListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: currentPage,
+				PageSize:   50,
+			},
+		}
+
+		varList, err := client.VariableSetVariables.List(context.Background(), variableSetID, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range varList.Items {
+			results = append(results, newVariableSetVariable(v))
+		}
+
+		if varList.NextPage == 0 {
+			break
+		}
+
+		currentPage++
+	}
+
+	return results, nil
+}
+
+// outputVariableSet reads the variable set and outputs it with its variables.
+func outputVariableSet(cmd *cobra.Command, client *tfe.Client, variableSetID string) {
+	vs, err := readVariableSet(client, variableSetID)
+	check(err)
+
+	variables, err := listVariableSetVariables(client, variableSetID)
+	check(err)
+
+	varsetJson, _ := json.MarshalIndent(VariableSetVars{
+		VariableSet: newVariableSet(vs),
+		Variables:   variables,
+	}, "", "  ")
+	outputData(cmd, varsetJson)
+}
+
+func getVariableSetScopeFlags(cmd *cobra.Command) (string, []string, []string) {
+	id, _ := cmd.Flags().GetString("id")
+	workspaces, _ := cmd.Flags().GetString("workspaces")
+	projects, _ := cmd.Flags().GetString("projects")
+
+	if workspaces == "" && projects == "" {
+		log.Fatal("please provide workspaces and/or projects to perform this operation!")
+	}
+
+	return id, splitIDs(workspaces), splitIDs(projects)
+}
+
+func applyVariableSet(client *tfe.Client, variableSetID string, workspaceIDs []string, projectIDs []string) error {
+	if len(workspaceIDs) > 0 {
+		options := &tfe.VariableSetApplyToWorkspacesOptions{}
+		for _, id := range workspaceIDs {
+			options.Workspaces = append(options.Workspaces, &tfe.Workspace{ID: id})
+		}
+
+		log.Debugf("Applying variable set %s to workspaces: %v", variableSetID, workspaceIDs)
+		if err := client.VariableSets.ApplyToWorkspaces(context.Background(), variableSetID, options); err != nil {
+			return err
+		}
+	}
+
+	if len(projectIDs) > 0 {
+		options := tfe.VariableSetApplyToProjectsOptions{}
+		for _, id := range projectIDs {
+			options.Projects = append(options.Projects, &tfe.Project{ID: id})
+		}
+
+		log.Debugf("Applying variable set %s to projects: %v", variableSetID, projectIDs)
+		if err := client.VariableSets.ApplyToProjects(context.Background(), variableSetID, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func removeVariableSet(client *tfe.Client, variableSetID string, workspaceIDs []string, projectIDs []string) error {
+	if len(workspaceIDs) > 0 {
+		options := &tfe.VariableSetRemoveFromWorkspacesOptions{}
+		for _, id := range workspaceIDs {
+			options.Workspaces = append(options.Workspaces, &tfe.Workspace{ID: id})
+		}
+
+		log.Debugf("Removing variable set %s from workspaces: %v", variableSetID, workspaceIDs)
+		if err := client.VariableSets.RemoveFromWorkspaces(context.Background(), variableSetID, options); err != nil {
+			return err
+		}
+	}
+
+	if len(projectIDs) > 0 {
+		options := tfe.VariableSetRemoveFromProjectsOptions{}
+		for _, id := range projectIDs {
+			options.Projects = append(options.Projects, &tfe.Project{ID: id})
+		}
+
+		log.Debugf("Removing variable set %s from projects: %v", variableSetID, projectIDs)
+		if err := client.VariableSets.RemoveFromProjects(context.Background(), variableSetID, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getVariableSetUsage(client *tfe.Client, organization string, filter string) ([]VariableSetUsage, error) {
+	results := []VariableSetUsage{}
+
+	variableSets, err := listVariableSets(client, organization, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces, err := listWorkspaces(client, organization, "")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vs := range variableSets {
+		log.Debugf("Processing variable set: %s - %s", vs.Name, vs.ID)
+
+		usage := VariableSetUsage{
+			VariableSetID:   vs.ID,
+			VariableSetName: vs.Name,
+			Global:          vs.Global,
+			Priority:        vs.Priority,
+			Workspaces:      []VariableSetWorkspace{},
+		}
+
+		for _, workspace := range workspaces {
+			via := variableSetScope(vs, workspace)
+			if via == "" {
+				continue
+			}
+
+			usage.Workspaces = append(usage.Workspaces, VariableSetWorkspace{
+				WorkspaceLite: WorkspaceLite{
+					WorkspaceID:   workspace.ID,
+					WorkspaceName: workspace.Name,
+				},
+				Via: via,
+			})
+		}
+
+		usage.WorkspaceCount = len(usage.Workspaces)
+		results = append(results, usage)
+	}
+
+	return results, nil
+}
+
+// variableSetScope returns how the variable set reaches the workspace:
+// workspace, project or global, or an empty string when it doesn't.
+func variableSetScope(vs *tfe.VariableSet, workspace *tfe.Workspace) string {
+	for _, w := range vs.Workspaces {
+		if w.ID == workspace.ID {
+			return "workspace"
+		}
+	}
+
+	if workspace.Project != nil {
+		for _, p := range vs.Projects {
+			if p.ID == workspace.Project.ID {
+				return "project"
+			}
+		}
+	}
+
+	if vs.Global {
+		return "global"
+	}
+
+	return ""
+}
+
+func newVariableSet(vs *tfe.VariableSet) VariableSet {
+	result := VariableSet{
+		ID:            vs.ID,
+		Name:          vs.Name,
+		Description:   vs.Description,
+		Global:        vs.Global,
+		Priority:      vs.Priority,
+		Workspaces:    []string{},
+		Projects:      []string{},
+		VariableCount: len(vs.Variables),
+	}
+
+	for _, w := range vs.Workspaces {
+		result.Workspaces = append(result.Workspaces, w.ID)
+	}
+	for _, p := range vs.Projects {
+		result.Projects = append(result.Projects, p.ID)
+	}
+
+	result.WorkspaceCount = len(result.Workspaces)
+	result.ProjectCount = len(result.Projects)
+
+	return result
+}
+
+func newVariableSetVariable(v *tfe.VariableSetVariable) Variable {
+	return Variable{
+		ID:          v.ID,
+		Key:         v.Key,
+		Value:       v.Value,
+		Description: v.Description,
+		Category:    v.Category,
+		HCL:         v.HCL,
+		Sensitive:   v.Sensitive,
+	}
+}
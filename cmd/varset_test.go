@@ -0,0 +1,31 @@
+//go:build all
+// +build all
+
+package cmd
+
+import (
+	"testing"
+)
+
+func TestVarsetListCmd(t *testing.T) {
+
+	tt := []struct {
+		args []string
+		err  error
+	}{
+		{
+			args: []string{"varset", "list"},
+			err:  nil,
+		},
+	}
+
+	r := rootCmd
+	c1 := varsetCmd
+	c2 := varsetListCmd
+	r.AddCommand(c1, c2)
+
+	runTestCasesNoOutput(t, r, tt)
+
+	r.RemoveCommand(c1, c2)
+	r.AddCommand(c1)
+}